@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Pass triggers recorded with every audit event.
+const (
+	triggerInitial = "initial" // the daemon's first pass
+	triggerFile    = "file"    // the topology file changed
+	triggerTimer   = "timer"   // periodic pass or retry
+)
+
+// auditEvent is one line of projects/<name>/logs/events.jsonl.
+type auditEvent struct {
+	Time    time.Time `json:"time"`
+	Pass    string    `json:"pass,omitempty"`
+	Trigger string    `json:"trigger,omitempty"`
+	Action  string    `json:"action"`         // e.g. node.delete, link.create, terraform.import
+	Node    string    `json:"node,omitempty"` // topology name
+	Link    string    `json:"link,omitempty"` // R1:1/0--R2:1/0
+	ID      string    `json:"id,omitempty"`   // GNS3 node or link ID
+	Detail  string    `json:"detail,omitempty"`
+	Result  string    `json:"result"`          // ok or error
+	Error   string    `json:"error,omitempty"` // GNS3 response or command output
+	Command string    `json:"command,omitempty"`
+	Exit    *int      `json:"exit,omitempty"` // exit status of Command
+}
+
+// auditLog appends the reconcile daemon's actions to a JSON-lines file. A
+// nil *auditLog records nothing, so plan and dry runs leave no trace.
+type auditLog struct {
+	mu      sync.Mutex
+	path    string
+	pass    string
+	trigger string
+}
+
+// audit is the daemon's event log; nil outside the daemon.
+var audit *auditLog
+
+func auditPath(projectName string) string {
+	return filepath.Join("projects", projectName, "logs", "events.jsonl")
+}
+
+// beginPass starts a new pass ID that tags every event until the next one.
+func (a *auditLog) beginPass(projectName, trigger string) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.path = auditPath(projectName)
+	a.pass = time.Now().UTC().Format("20060102T150405.000Z")
+	a.trigger = trigger
+	a.mu.Unlock()
+}
+
+// currentPass returns the ID and trigger of the running pass, for work that
+// outlives it such as healing.
+func (a *auditLog) currentPass() (pass, trigger string) {
+	if a == nil {
+		return "", ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.pass, a.trigger
+}
+
+// record appends ev, tagged with the time and the current pass unless ev
+// carries its own pass or trigger, and with the outcome err. Write failures are logged and
+// never fail the pass. The event is also counted in the daemon's metrics.
+func (a *auditLog) record(ev auditEvent, err error) {
+	metrics.observe(ev, err)
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.path == "" {
+		return
+	}
+	ev.Time = time.Now().UTC()
+	if ev.Pass == "" && ev.Trigger == "" {
+		ev.Pass, ev.Trigger = a.pass, a.trigger
+	}
+	ev.Result = "ok"
+	if err != nil {
+		ev.Result, ev.Error = "error", err.Error()
+	}
+	line, _ := json.Marshal(ev)
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ audit log: %v\n", err)
+		return
+	}
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ audit log: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ audit log: %v\n", err)
+	}
+}
+
+// recordCommand records ev for a command that ran, with its exit status
+// and, when it failed, its output.
+func (a *auditLog) recordCommand(ev auditEvent, cmd *exec.Cmd, out []byte, err error) {
+	ev.Command = strings.Join(cmd.Args, " ")
+	exit := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		exit = exitErr.ExitCode()
+	case err != nil:
+		exit = -1
+	}
+	ev.Exit = &exit
+	if err != nil {
+		err = fmt.Errorf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+	a.record(ev, err)
+}
+
+// linkLabel renders a link as R1:1/0--R2:1/0, naming nodes by names (node
+// ID → name) where it can.
+func linkLabel(l ObservedLink, names map[string]string) string {
+	var ends []string
+	for _, ep := range l.Nodes {
+		name, ok := names[ep.NodeID]
+		if !ok {
+			name = ep.NodeID
+		}
+		ends = append(ends, fmt.Sprintf("%s:%d/%d", name, ep.AdapterNumber, ep.PortNumber))
+	}
+	return strings.Join(ends, "--")
+}
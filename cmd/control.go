@@ -0,0 +1,420 @@
+package cmd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Triggers of passes requested through the control API.
+const (
+	triggerAPI     = "api"     // netdevops daemon reconcile
+	triggerWebhook = "webhook" // a Git push
+)
+
+// webhookSecretEnv names the variable holding the secret Git webhooks must
+// present. It is read from the environment so it never shows in ps.
+const webhookSecretEnv = "NETDEVOPS_WEBHOOK_SECRET"
+
+func controlSocketPath(projectName string) string {
+	return filepath.Join("projects", projectName, "reconcile.sock")
+}
+
+// daemonStatus is what GET /status reports about a running daemon.
+type daemonStatus struct {
+	PID                 int         `json:"pid"`
+	Project             string      `json:"project"`
+	ProjectID           string      `json:"project_id"`
+	Started             time.Time   `json:"started"`
+	State               string      `json:"state"` // idle, running or paused
+	PausedUntil         *time.Time  `json:"paused_until,omitempty"`
+	Passes              int         `json:"passes"`
+	ConsecutiveFailures int         `json:"consecutive_failures"`
+	LastPass            *passStatus `json:"last_pass,omitempty"`
+	LastSuccess         *time.Time  `json:"last_success,omitempty"`
+	NextPass            *time.Time  `json:"next_pass,omitempty"`
+	TopologyError       string      `json:"topology_error,omitempty"` // why the file was last rejected
+	Desired             itemCounts  `json:"desired"`
+	Observed            itemCounts  `json:"observed"` // in GNS3 after the last pass
+}
+
+type passStatus struct {
+	Trigger  string    `json:"trigger"`
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+type itemCounts struct {
+	Nodes int `json:"nodes"`
+	Links int `json:"links"`
+}
+
+// daemonControl is the reconcile daemon's control API: status, on-demand
+// passes, pause and resume, and Git webhooks. It serves HTTP on the
+// project's Unix socket and, for webhooks only, on --webhook-addr. A nil
+// *daemonControl records nothing.
+type daemonControl struct {
+	mu       sync.Mutex
+	status   daemonStatus
+	paused   bool
+	listened bool        // listen was called
+	socket   string      // path of the Unix socket once listening
+	triggers chan string // passes requested, by trigger
+	secret   string      // webhook secret
+	gitDir   string      // pulled before a webhook pass, if a Git work tree
+	servers  []*http.Server
+}
+
+// control is the running daemon's control API; nil outside the daemon.
+var control *daemonControl
+
+func newDaemonControl(yamlPath, projectID string) *daemonControl {
+	return &daemonControl{
+		status:   daemonStatus{PID: os.Getpid(), ProjectID: projectID, Started: time.Now(), State: "idle"},
+		triggers: make(chan string, 1),
+		secret:   os.Getenv(webhookSecretEnv),
+		gitDir:   filepath.Dir(yamlPath),
+	}
+}
+
+// listen serves the control API on the project's socket, once the project
+// name is known. A socket another daemon still answers on is left alone.
+func (c *daemonControl) listen(projectName string) {
+	if c == nil || c.listened {
+		return
+	}
+	c.listened = true
+	path := controlSocketPath(projectName)
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		fmt.Fprintf(os.Stderr, "❌ another reconcile daemon is listening on %s; control API disabled\n", path)
+		return
+	}
+	os.Remove(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ control socket: %v\n", err)
+		return
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ control socket: %v\n", err)
+		return
+	}
+	os.Chmod(path, 0600)
+	c.mu.Lock()
+	c.socket, c.status.Project = path, projectName
+	c.mu.Unlock()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", c.handleStatus)
+	mux.HandleFunc("/reconcile", c.handleReconcile)
+	mux.HandleFunc("/pause", c.handlePause)
+	mux.HandleFunc("/resume", c.handleResume)
+	mux.HandleFunc("/webhook", c.handleWebhook)
+	c.serve(ln, mux)
+	fmt.Printf("🎛️  Control API on %s\n", path)
+}
+
+// checkWebhookSecret refuses a webhook listener without a secret unless
+// insecure explicitly allows it: unauthenticated webhooks let anyone who
+// reaches the port trigger passes and a git pull.
+func checkWebhookSecret(insecure bool) error {
+	if os.Getenv(webhookSecretEnv) == "" && !insecure {
+		return fmt.Errorf("--webhook-addr needs a secret in $%s (or --webhook-insecure to accept unauthenticated webhooks)", webhookSecretEnv)
+	}
+	return nil
+}
+
+// listenWebhook serves POST /webhook on a TCP address, for Git servers.
+// Without a secret it only starts when insecure is set.
+func (c *daemonControl) listenWebhook(addr string, insecure bool) error {
+	if err := checkWebhookSecret(insecure); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("webhook listener: %w", err)
+	}
+	if c.secret == "" {
+		fmt.Fprintf(os.Stderr, "⚠️ %s is not set and --webhook-insecure is on: anyone reaching %s can trigger passes\n", webhookSecretEnv, ln.Addr())
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/webhook", c.handleWebhook)
+	c.serve(ln, mux)
+	fmt.Printf("🪝 Git webhook on http://%s/webhook\n", ln.Addr())
+	return nil
+}
+
+func (c *daemonControl) serve(ln net.Listener, h http.Handler) {
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 10 * time.Second}
+	c.mu.Lock()
+	c.servers = append(c.servers, srv)
+	c.mu.Unlock()
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			fmt.Fprintf(os.Stderr, "❌ control API: %v\n", err)
+		}
+	}()
+}
+
+// close stops the listeners and removes the socket.
+func (c *daemonControl) close() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, srv := range c.servers {
+		srv.Close()
+	}
+	if c.socket != "" {
+		os.Remove(c.socket)
+	}
+}
+
+// isPaused reports whether passes are paused, resuming once a timed pause
+// is over.
+func (c *daemonControl) isPaused() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused && c.status.PausedUntil != nil && time.Now().After(*c.status.PausedUntil) {
+		c.paused, c.status.PausedUntil, c.status.State = false, nil, "idle"
+		fmt.Println("▶️  Pause expired; reconciliation resumed")
+	}
+	return c.paused
+}
+
+func (c *daemonControl) passStarted(trigger string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.State = "running"
+	c.status.NextPass = nil
+	c.status.LastPass = &passStatus{Trigger: trigger, Started: time.Now()}
+}
+
+func (c *daemonControl) passDone(err error, failures int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.paused {
+		c.status.State = "paused"
+	} else {
+		c.status.State = "idle"
+	}
+	c.status.Passes++
+	c.status.ConsecutiveFailures = failures
+	p := c.status.LastPass
+	p.Duration = time.Since(p.Started).Round(time.Millisecond).String()
+	if err != nil {
+		p.Error = err.Error()
+		return
+	}
+	now := time.Now()
+	c.status.LastSuccess = &now
+}
+
+// topologyRejected records why the last reload failed; nil clears it.
+func (c *daemonControl) topologyRejected(err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.TopologyError = ""
+	if err != nil {
+		c.status.TopologyError = err.Error()
+	}
+}
+
+// counts records the desired and observed nodes and links of a pass.
+func (c *daemonControl) counts(desired, observed itemCounts) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.Desired, c.status.Observed = desired, observed
+}
+
+func (c *daemonControl) nextPass(at time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.NextPass = &at
+}
+
+// request queues a pass; a pass already queued absorbs it.
+func (c *daemonControl) request(trigger string) {
+	select {
+	case c.triggers <- trigger:
+	default:
+	}
+}
+
+// pending returns the channel of requested passes; nil never fires.
+func (c *daemonControl) pending() <-chan string {
+	if c == nil {
+		return nil
+	}
+	return c.triggers
+}
+
+func (c *daemonControl) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	c.isPaused() // expire a timed pause before reporting it
+	c.mu.Lock()
+	st := c.status
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(st)
+}
+
+func (c *daemonControl) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if c.isPaused() {
+		http.Error(w, "reconciliation is paused; resume it first", http.StatusConflict)
+		return
+	}
+	c.request(triggerAPI)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(w, "reconcile pass queued")
+}
+
+// handlePause pauses passes, for ?for=<duration> or until resumed.
+func (c *daemonControl) handlePause(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var until *time.Time
+	if s := r.URL.Query().Get("for"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid duration %q", s), http.StatusBadRequest)
+			return
+		}
+		t := time.Now().Add(d)
+		until = &t
+	}
+	c.mu.Lock()
+	c.paused, c.status.PausedUntil = true, until
+	if c.status.State != "running" {
+		c.status.State = "paused"
+	}
+	c.mu.Unlock()
+	msg := "reconciliation paused until resumed"
+	if until != nil {
+		msg = "reconciliation paused until " + until.Format(time.RFC3339)
+	}
+	fmt.Printf("⏸️  %s\n", strings.ToUpper(msg[:1])+msg[1:])
+	audit.record(auditEvent{Trigger: triggerAPI, Action: "daemon.pause", Detail: msg}, nil)
+	fmt.Fprintln(w, msg)
+}
+
+func (c *daemonControl) handleResume(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	c.mu.Lock()
+	c.paused, c.status.PausedUntil = false, nil
+	if c.status.State == "paused" {
+		c.status.State = "idle"
+	}
+	c.mu.Unlock()
+	fmt.Println("▶️  Reconciliation resumed")
+	audit.record(auditEvent{Trigger: triggerAPI, Action: "daemon.resume"}, nil)
+	fmt.Fprintln(w, "reconciliation resumed")
+}
+
+// handleWebhook queues a pass for a Git push. With a secret set, GitHub's
+// X-Hub-Signature-256 or GitLab's X-Gitlab-Token must match it; without
+// one, the webhook is only served with --webhook-insecure.
+func (c *daemonControl) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "cannot read body", http.StatusBadRequest)
+		return
+	}
+	if !c.webhookAuthorized(r, body) {
+		http.Error(w, "invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		fmt.Fprintln(w, "pong")
+		return
+	}
+	if c.isPaused() {
+		http.Error(w, "reconciliation is paused", http.StatusConflict)
+		return
+	}
+	c.request(triggerWebhook)
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintln(w, "reconcile pass queued")
+}
+
+func (c *daemonControl) webhookAuthorized(r *http.Request, body []byte) bool {
+	if c.secret == "" {
+		return true
+	}
+	if sig, ok := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256="); ok {
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(body)
+		want := hex.EncodeToString(mac.Sum(nil))
+		return hmac.Equal([]byte(sig), []byte(want))
+	}
+	token := r.Header.Get("X-Gitlab-Token")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(c.secret)) == 1
+}
+
+// pullTopology fast-forwards the topology's Git work tree before a webhook
+// pass, so the pass sees the pushed commit. Outside a work tree it does
+// nothing.
+func (c *daemonControl) pullTopology() {
+	check := exec.Command("git", "rev-parse", "--is-inside-work-tree")
+	check.Dir = c.gitDir
+	if err := check.Run(); err != nil {
+		return
+	}
+	fmt.Printf("📥 Pulling %s…\n", c.gitDir)
+	pull := exec.Command("git", "pull", "--ff-only")
+	pull.Dir = c.gitDir
+	out, err := pull.CombinedOutput()
+	audit.recordCommand(auditEvent{Trigger: triggerWebhook, Action: "git.pull"}, pull, out, err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ git pull failed, reconciling the current checkout: %v\n%s\n", err, out)
+	}
+}
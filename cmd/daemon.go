@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var (
+	daemonJSON     bool
+	daemonPauseFor time.Duration
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Talk to the running reconcile daemon",
+	Long: `Query and steer the reconcile daemon started by gns3-deploy through its control
+socket, projects/<name>/reconcile.sock.
+
+  netdevops daemon status -c topology.yaml
+  netdevops daemon pause -c topology.yaml --for 30m   # before manual GUI work
+  netdevops daemon resume -c topology.yaml
+  netdevops daemon reconcile -c topology.yaml         # run a pass now`,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the reconcile daemon's state, last pass and counts",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		body, err := daemonRequest(http.MethodGet, "/status")
+		if err != nil {
+			return err
+		}
+		if daemonJSON {
+			fmt.Print(body)
+			return nil
+		}
+		var st daemonStatus
+		if err := json.Unmarshal([]byte(body), &st); err != nil {
+			return fmt.Errorf("invalid status from daemon: %w", err)
+		}
+		printDaemonStatus(st)
+		return nil
+	},
+}
+
+var daemonReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Run a reconcile pass now",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return daemonPost("/reconcile")
+	},
+}
+
+var daemonPauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pause reconciliation, e.g. during manual work in the GNS3 GUI",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := "/pause"
+		if daemonPauseFor > 0 {
+			path += "?for=" + url.QueryEscape(daemonPauseFor.String())
+		}
+		return daemonPost(path)
+	},
+}
+
+var daemonResumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume reconciliation",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return daemonPost("/resume")
+	},
+}
+
+func init() {
+	daemonCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	daemonStatusCmd.Flags().BoolVar(&daemonJSON, "json", false, "print the raw status JSON")
+	daemonPauseCmd.Flags().DurationVar(&daemonPauseFor, "for", 0, "resume automatically after this long (default: until resumed)")
+	daemonCmd.AddCommand(daemonStatusCmd, daemonReconcileCmd, daemonPauseCmd, daemonResumeCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
+
+// daemonRequest calls the control API of the daemon reconciling the
+// topology's project and returns the response body. Only project.name is
+// read, so the daemon can be reached while the file is invalid.
+func daemonRequest(method, path string) (string, error) {
+	name, err := topology.ProjectName(configFile)
+	if err != nil {
+		return "", err
+	}
+	socket := controlSocketPath(name)
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	req, err := http.NewRequest(method, "http://daemon"+path, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no reconcile daemon answering on %s (is gns3-deploy running?): %w", socket, err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("daemon: %s", strings.TrimSpace(string(data)))
+	}
+	return string(data), nil
+}
+
+func daemonPost(path string) error {
+	body, err := daemonRequest(http.MethodPost, path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ %s\n", strings.TrimSpace(body))
+	return nil
+}
+
+func printDaemonStatus(st daemonStatus) {
+	state := colorGreen + st.State + colorReset
+	switch st.State {
+	case "paused":
+		state = colorYellow + st.State + colorReset
+		if st.PausedUntil != nil {
+			state += " until " + st.PausedUntil.Local().Format("15:04:05")
+		}
+	case "running":
+		state = colorCyan + st.State + colorReset
+	}
+	fmt.Printf("🤖 Reconcile daemon for %s (project %s), pid %d\n", st.Project, st.ProjectID, st.PID)
+	fmt.Printf("   State:     %s, up %s, %d passes\n", state, time.Since(st.Started).Round(time.Second), st.Passes)
+	if p := st.LastPass; p != nil {
+		result := colorGreen + "ok" + colorReset
+		if p.Error != "" {
+			result = colorRed + p.Error + colorReset
+		} else if p.Duration == "" {
+			result = "in progress"
+		}
+		fmt.Printf("   Last pass: %s (%s), %s: %s\n", p.Started.Local().Format("2006-01-02 15:04:05"), p.Trigger, orNone(p.Duration), result)
+	}
+	if st.LastSuccess != nil {
+		fmt.Printf("   Last ok:   %s\n", st.LastSuccess.Local().Format("2006-01-02 15:04:05"))
+	}
+	if st.ConsecutiveFailures > 0 {
+		fmt.Printf("   %sFailures:  %d in a row%s\n", colorRed, st.ConsecutiveFailures, colorReset)
+	}
+	if st.NextPass != nil && st.State != "paused" {
+		fmt.Printf("   Next pass: in %s\n", time.Until(*st.NextPass).Round(time.Second))
+	}
+	if st.TopologyError != "" {
+		fallback := "reconciling the last good one"
+		if st.LastPass == nil {
+			fallback = "waiting for a valid one"
+		}
+		fmt.Printf("   %sTopology rejected, %s:%s %s\n", colorRed, fallback, colorReset, st.TopologyError)
+	}
+	fmt.Printf("   Nodes:     %d desired, %d in GNS3\n", st.Desired.Nodes, st.Observed.Nodes)
+	fmt.Printf("   Links:     %d desired, %d in GNS3\n", st.Desired.Links, st.Observed.Links)
+}
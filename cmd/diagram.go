@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diagramFormat string
+	diagramOutput string
+)
+
+// diagramExtensions picks the format from the output file name when
+// --format is not given.
+var diagramExtensions = map[string]string{
+	".dot":     "dot",
+	".gv":      "dot",
+	".mmd":     "mermaid",
+	".mermaid": "mermaid",
+	".drawio":  "drawio",
+	".svg":     "svg",
+}
+
+var diagramCmd = &cobra.Command{
+	Use:   "diagram",
+	Short: "Render the topology as a Graphviz, Mermaid, draw.io or SVG diagram",
+	Long: `Render the topology as a diagram for design docs and merge requests. Nodes
+are grouped by type; every link is labelled with the interface name and
+adapter/port at each end and the IP subnet configured on it. The diagram is
+built from the YAML alone, no GNS3 server needed. draw.io and SVG keep the
+canvas positions of the nodes.
+
+  netdevops diagram -c topology.yaml --format mermaid
+  netdevops diagram -c topology.yaml -o topology.svg`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := diagramFormat
+		if !cmd.Flags().Changed("format") {
+			if f, ok := diagramExtensions[strings.ToLower(filepath.Ext(diagramOutput))]; ok {
+				format = f
+			}
+		}
+
+		topo, err := loadTopologyReadOnly(configFile)
+		if err != nil {
+			return err
+		}
+		d := topo.Diagram()
+		var data []byte
+		switch format {
+		case "dot":
+			data = d.DOT()
+		case "mermaid":
+			data = d.Mermaid()
+		case "drawio":
+			data = d.DrawIO()
+		case "svg":
+			data = d.SVG()
+		default:
+			return fmt.Errorf("unknown diagram format %q (use dot, mermaid, drawio or svg)", format)
+		}
+
+		if diagramOutput == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(diagramOutput, data, 0644); err != nil {
+			return fmt.Errorf("could not write diagram to %s: %w", diagramOutput, err)
+		}
+		fmt.Printf("✅ %s diagram written to %s\n", format, diagramOutput)
+		return nil
+	},
+}
+
+func init() {
+	diagramCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	diagramCmd.Flags().StringVarP(&diagramFormat, "format", "f", "dot", "diagram format: dot, mermaid, drawio or svg")
+	diagramCmd.Flags().StringVarP(&diagramOutput, "output", "o", "", "write the diagram to this file instead of stdout")
+	rootCmd.AddCommand(diagramCmd)
+}
@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var diffFormat string
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old.yaml> <new.yaml>",
+	Short: "Show what changes between two revisions of a topology",
+	Long: `Load two topology files and report what the lab would do differently:
+nodes added, removed or changed, links added, removed or re-cabled, interface
+addresses and routing protocol settings. Nodes are identified by name and
+links by the nodes and ports they join, as the reconciler does. Both files are loaded
+like every other command loads them (fabric expanded, defaults, IPAM from
+the lockfile), without updating any lockfile.
+
+  netdevops diff <(git show HEAD~1:topology.yaml) topology.yaml
+  netdevops diff old.yaml new.yaml --format json`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if diffFormat != "text" && diffFormat != "json" {
+			return fmt.Errorf("unknown diff format %q (use text or json)", diffFormat)
+		}
+		var topos [2]Topology
+		for i, path := range args {
+			t, err := topology.LoadReadOnly(path)
+			if err != nil {
+				return fmt.Errorf("cannot load %s: %w", path, err)
+			}
+			topos[i] = t
+		}
+		d := diffTopologies(topos[0], topos[1])
+
+		if diffFormat == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(d)
+		}
+		printTopologyDiff(d)
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "f", "text", "output format: text or json")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// topologyDiff is the semantic difference between two topologies.
+type topologyDiff struct {
+	Nodes     []diffEntry `json:"nodes"`
+	Links     []diffEntry `json:"links"`
+	Addresses []diffEntry `json:"addresses"`
+	Routing   []diffEntry `json:"routing"`
+}
+
+// diffEntry is one added, removed or changed item.
+type diffEntry struct {
+	Change string        `json:"change"` // added, removed or changed
+	Name   string        `json:"name"`
+	Fields []fieldChange `json:"fields,omitempty"`
+}
+
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+func (d topologyDiff) empty() bool {
+	return len(d.Nodes)+len(d.Links)+len(d.Addresses)+len(d.Routing) == 0
+}
+
+func diffTopologies(old, new Topology) topologyDiff {
+	return topologyDiff{
+		Nodes:     diffNodes(old, new),
+		Links:     diffTopologyLinks(old, new),
+		Addresses: diffFlat(interfaceAddresses(old), interfaceAddresses(new)),
+		Routing:   diffFlat(routingSettings(old), routingSettings(new)),
+	}
+}
+
+// diffNodes compares the nodes the reconciler would create for each
+// topology, matched by name.
+func diffNodes(old, new Topology) []diffEntry {
+	flat := func(t Topology) map[string]map[string]string {
+		nodes, _ := BuildDesired(t)
+		out := make(map[string]map[string]string, len(nodes))
+		for _, n := range nodes {
+			f := map[string]string{
+				"type":     n.ResourceType,
+				"template": n.TemplateName,
+			}
+			for k, v := range n.Properties {
+				f[k] = fmt.Sprint(v)
+			}
+			// Positions chosen by the layout shift with unrelated
+			// changes; only those written in the file are compared.
+			if n.Position.Placed() && !n.Position.Computed() {
+				x, y := n.Position.XY()
+				f["position"] = fmt.Sprintf("%d,%d", x, y)
+			}
+			out[n.Name] = f
+		}
+		return out
+	}
+	return diffFlat(flat(old), flat(new))
+}
+
+// diffTopologyLinks groups links by the nodes they join, then matches them
+// by ports as the reconciler does, so a link moved to another port is
+// reported as changed rather than removed and added.
+func diffTopologyLinks(old, new Topology) []diffEntry {
+	group := func(t Topology) map[linkKey][]Link {
+		m := make(map[linkKey][]Link)
+		for _, l := range t.Links {
+			if len(l.Endpoints) == 2 {
+				k := newLinkKey(l.Endpoints[0].Name, l.Endpoints[1].Name)
+				m[k] = append(m[k], l)
+			}
+		}
+		return m
+	}
+	oldLinks, newLinks := group(old), group(new)
+	keys := make(map[linkKey]bool)
+	for k := range oldLinks {
+		keys[k] = true
+	}
+	for k := range newLinks {
+		keys[k] = true
+	}
+
+	out := []diffEntry{}
+	for k := range keys {
+		gone := append([]Link(nil), oldLinks[k]...)
+		var added []Link
+		for _, n := range newLinks[k] {
+			matched := false
+			for i, o := range gone {
+				if linkPorts(o, k) == linkPorts(n, k) {
+					gone = append(gone[:i], gone[i+1:]...)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				added = append(added, n)
+			}
+		}
+		for len(gone) > 0 && len(added) > 0 {
+			out = append(out, diffEntry{
+				Change: "changed",
+				Name:   k.a + " -- " + k.b,
+				Fields: []fieldChange{{Field: "ports", Old: describeLink(old, gone[0]), New: describeLink(new, added[0])}},
+			})
+			gone, added = gone[1:], added[1:]
+		}
+		for _, l := range gone {
+			out = append(out, diffEntry{Change: "removed", Name: describeLink(old, l)})
+		}
+		for _, l := range added {
+			out = append(out, diffEntry{Change: "added", Name: describeLink(new, l)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// linkPorts renders a link's adapter/port pairs in the order of its key, so
+// swapped endpoints compare equal.
+func linkPorts(l Link, k linkKey) string {
+	a, b := l.Endpoints[0], l.Endpoints[1]
+	if a.Name != k.a {
+		a, b = b, a
+	}
+	return fmt.Sprintf("%d/%d-%d/%d", a.Adapter, a.Port, b.Adapter, b.Port)
+}
+
+// describeLink names a link by its interfaces, e.g.
+// R1:Ethernet1 (1/0) -- R2:Ethernet1 (1/0).
+func describeLink(t Topology, l Link) string {
+	platforms := t.Platforms()
+	var ends []string
+	for _, e := range l.Endpoints {
+		iface := topology.InterfaceName(platforms[e.Name], e.Adapter, e.Port)
+		ends = append(ends, fmt.Sprintf("%s:%s (%d/%d)", e.Name, iface, e.Adapter, e.Port))
+	}
+	sort.Strings(ends)
+	return strings.Join(ends, " -- ")
+}
+
+// interfaceAddresses maps router → interface → ip_address, with interface
+// names normalised so Et1 and Ethernet1 compare equal.
+func interfaceAddresses(t Topology) map[string]map[string]string {
+	platforms := t.Platforms()
+	out := make(map[string]map[string]string)
+	for _, r := range t.ConfiguredRouters() {
+		for _, c := range r.Config {
+			if c == nil || c.Interface == "" || c.IPAddress == "" {
+				continue
+			}
+			iface := c.Interface
+			if a, p, err := topology.InterfacePort(platforms[r.Name], iface); err == nil {
+				iface = topology.InterfaceName(platforms[r.Name], a, p)
+			}
+			if out[r.Name] == nil {
+				out[r.Name] = make(map[string]string)
+			}
+			out[r.Name][iface] = c.IPAddress
+		}
+	}
+	return out
+}
+
+// routingSettings flattens each router's OSPF, BGP and static routes to
+// field → value, e.g. ospf.area → 0.
+func routingSettings(t Topology) map[string]map[string]string {
+	out := make(map[string]map[string]string)
+	for _, r := range t.ConfiguredRouters() {
+		f := make(map[string]string)
+		for _, c := range r.Config {
+			if c == nil {
+				continue
+			}
+			if c.OSPF != nil {
+				flattenYAML(f, "ospf", c.OSPF)
+			}
+			if c.BGP != nil {
+				flattenYAML(f, "bgp", c.BGP)
+			}
+			for _, sr := range c.StaticRoutes {
+				route := fmt.Sprintf("static_route %s/%s", sr.DestNetwork, sr.SubnetMask)
+				f[route] = strings.TrimSpace("via " + sr.NextHop + " " + sr.Interface)
+			}
+		}
+		if len(f) > 0 {
+			out[r.Name] = f
+		}
+	}
+	return out
+}
+
+// flattenYAML writes v into f as dotted paths. Lists of scalars become one
+// sorted, comma-separated value so reordering them is not a change.
+func flattenYAML(f map[string]string, prefix string, v interface{}) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return
+	}
+	var tree interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return
+	}
+	var walk func(path string, n interface{})
+	walk = func(path string, n interface{}) {
+		switch n := n.(type) {
+		case map[string]interface{}:
+			for k, v := range n {
+				walk(path+"."+k, v)
+			}
+		case []interface{}:
+			var scalars []string
+			for i, item := range n {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}:
+					walk(fmt.Sprintf("%s[%d]", path, i), item)
+				default:
+					scalars = append(scalars, fmt.Sprint(item))
+				}
+			}
+			if len(scalars) > 0 {
+				sort.Strings(scalars)
+				f[path] = strings.Join(scalars, ", ")
+			}
+		case nil:
+		default:
+			f[path] = fmt.Sprint(n)
+		}
+	}
+	walk(prefix, tree)
+}
+
+// diffFlat compares two name → field → value maps.
+func diffFlat(old, new map[string]map[string]string) []diffEntry {
+	names := make(map[string]bool)
+	for n := range old {
+		names[n] = true
+	}
+	for n := range new {
+		names[n] = true
+	}
+	out := []diffEntry{}
+	for name := range names {
+		o, inOld := old[name]
+		n, inNew := new[name]
+		switch {
+		case !inOld:
+			out = append(out, diffEntry{Change: "added", Name: name, Fields: fieldChanges(nil, n)})
+		case !inNew:
+			out = append(out, diffEntry{Change: "removed", Name: name, Fields: fieldChanges(o, nil)})
+		default:
+			if fields := fieldChanges(o, n); len(fields) > 0 {
+				out = append(out, diffEntry{Change: "changed", Name: name, Fields: fields})
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func fieldChanges(old, new map[string]string) []fieldChange {
+	keys := make(map[string]bool)
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	var out []fieldChange
+	for k := range keys {
+		if old[k] != new[k] {
+			out = append(out, fieldChange{Field: k, Old: old[k], New: new[k]})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Field < out[j].Field })
+	return out
+}
+
+func printTopologyDiff(d topologyDiff) {
+	if d.empty() {
+		fmt.Println("✅ No differences")
+		return
+	}
+	marks := map[string]string{
+		"added":   colorGreen + "+",
+		"removed": colorRed + "-",
+		"changed": colorYellow + "~",
+	}
+	section := func(title string, entries []diffEntry, showFields bool) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Println(title)
+		for _, e := range entries {
+			fmt.Printf("  %s %s%s\n", marks[e.Change], e.Name, colorReset)
+			if e.Change != "changed" && !showFields {
+				continue
+			}
+			for _, f := range e.Fields {
+				switch {
+				case e.Change == "added":
+					fmt.Printf("      %s: %s\n", f.Field, f.New)
+				case e.Change == "removed":
+					fmt.Printf("      %s: %s\n", f.Field, f.Old)
+				default:
+					fmt.Printf("      %s: %s → %s\n", f.Field, orNone(f.Old), orNone(f.New))
+				}
+			}
+		}
+		fmt.Println()
+	}
+	section("📦 Nodes:", d.Nodes, false)
+	section("🔗 Links:", d.Links, false)
+	section("🌐 Interface addresses:", d.Addresses, true)
+	section("🧭 Routing:", d.Routing, true)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Drift policies: what the reconciler does when a property of a managed
+// node no longer matches the topology.
+const (
+	driftUpdate   = "update"   // change the property in place
+	driftRecreate = "recreate" // delete the node and create it again
+	driftWarn     = "warn"     // only report it
+)
+
+// driftRule is the built-in handling of one node property.
+type driftRule struct {
+	Policy    string
+	NeedsStop bool // GNS3 only applies the change to a stopped node
+}
+
+// driftRules lists the properties compared per GNS3 node type. Only
+// properties the topology sets are compared; VPCS, NAT and hub nodes have
+// none.
+var driftRules = map[string]map[string]driftRule{
+	"qemu": {
+		"hda_disk_image": {driftRecreate, true}, // the node's disk overlay belongs to the old image
+		"ram":            {driftUpdate, true},
+		"cpus":           {driftUpdate, true},
+		"adapters":       {driftUpdate, true},
+		"adapter_type":   {driftUpdate, true},
+		"mac_address":    {driftUpdate, true},
+		"platform":       {driftUpdate, true},
+		"options":        {driftUpdate, true},
+		"console_type":   {driftUpdate, false},
+	},
+	"docker": {
+		"image":         {driftRecreate, true},
+		"adapters":      {driftUpdate, true},
+		"start_command": {driftUpdate, true},
+		"environment":   {driftUpdate, true},
+		"console_type":  {driftUpdate, false},
+	},
+	"iou": {
+		"path":              {driftRecreate, true}, // startup config and NVRAM belong to the old image
+		"ram":               {driftUpdate, true},
+		"nvram":             {driftUpdate, true},
+		"ethernet_adapters": {driftUpdate, true},
+		"serial_adapters":   {driftUpdate, true},
+	},
+	"dynamips": {
+		"platform": {driftRecreate, true},
+		"image":    {driftUpdate, true},
+		"ram":      {driftUpdate, true},
+		"nvram":    {driftUpdate, true},
+		"slot0":    {driftUpdate, true},
+		"slot1":    {driftUpdate, true},
+		"slot2":    {driftUpdate, true},
+		"slot3":    {driftUpdate, true},
+		"slot4":    {driftUpdate, true},
+		"slot5":    {driftUpdate, true},
+		"slot6":    {driftUpdate, true},
+		"wic0":     {driftUpdate, true},
+		"wic1":     {driftUpdate, true},
+		"wic2":     {driftUpdate, true},
+		"idlepc":   {driftUpdate, false},
+	},
+}
+
+// isDriftField reports whether any node type compares field, so it can be
+// given a policy in project.drift.
+func isDriftField(field string) bool {
+	for _, rules := range driftRules {
+		if _, ok := rules[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeDrift is a managed node whose properties differ from the topology.
+type nodeDrift struct {
+	Name    string // topology name
+	Node    ObservedNode
+	Desired NodeCreatePayload
+	Changes []fieldChange // Old is GNS3's value, New the topology's
+	Action  string        // the strongest policy of the changed fields
+	Update  map[string]interface{}
+	Stop    bool // the node must be stopped for Update
+}
+
+// detectDrift compares the properties of every matched node with the
+// topology. overrides (project.drift) replace the built-in policy of a
+// property.
+func detectDrift(desired []NodeCreatePayload, m nodeMatch, overrides map[string]string) []nodeDrift {
+	var out []nodeDrift
+	for _, nd := range desired {
+		o, ok := m.Matched[nd.Name]
+		rules := driftRules[o.NodeType]
+		if !ok || rules == nil || len(nd.Properties) == 0 {
+			continue
+		}
+		d := nodeDrift{Name: nd.Name, Node: o, Desired: nd, Action: driftWarn, Update: map[string]interface{}{}}
+		for field, want := range nd.Properties {
+			rule, ok := rules[field]
+			if !ok || isZeroProperty(want) {
+				continue
+			}
+			have := o.Properties[field]
+			if samePropertyValue(field, have, want) {
+				continue
+			}
+			d.Changes = append(d.Changes, fieldChange{Field: field, Old: propertyString(have), New: propertyString(want)})
+			policy := rule.Policy
+			if p, ok := overrides[field]; ok {
+				policy = p
+			}
+			switch policy {
+			case driftRecreate:
+				d.Action = driftRecreate
+			case driftUpdate:
+				if d.Action != driftRecreate {
+					d.Action = driftUpdate
+				}
+				d.Update[field] = want
+				d.Stop = d.Stop || rule.NeedsStop
+			}
+		}
+		if len(d.Changes) > 0 {
+			sort.Slice(d.Changes, func(i, j int) bool { return d.Changes[i].Field < d.Changes[j].Field })
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// isZeroProperty reports whether the topology leaves a property to GNS3.
+func isZeroProperty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case int:
+		return v == 0
+	}
+	return false
+}
+
+// samePropertyValue compares a GNS3 property (decoded from JSON) with the
+// topology's value. MAC addresses ignore case and QEMU disk and IOU images
+// their directory, since GNS3 may report them with a path.
+func samePropertyValue(field string, have, want interface{}) bool {
+	h, w := propertyString(have), propertyString(want)
+	switch field {
+	case "mac_address":
+		return strings.EqualFold(h, w)
+	case "hda_disk_image", "path":
+		return path.Base(h) == path.Base(w)
+	}
+	return h == w
+}
+
+func propertyString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// describeChanges renders a drift's changes as "ram 2048 → 4096, ...",
+// marking those an update leaves alone by policy.
+func (d nodeDrift) describeChanges() string {
+	var parts []string
+	for _, c := range d.Changes {
+		part := fmt.Sprintf("%s %s → %s", c.Field, orNone(c.Old), orNone(c.New))
+		if _, fixed := d.Update[c.Field]; d.Action == driftUpdate && !fixed {
+			part += " (warn only)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fixDrift updates the drifted properties of a node in place, stopping it
+// first when GNS3 requires it and starting it again afterwards.
+func fixDrift(d nodeDrift, projectID string) error {
+	if d.Stop && d.Node.Status == "started" {
+		if err := stopNode(d.Node.ID, projectID); err != nil {
+			return err
+		}
+	}
+	if err := updateNodeProperties(d.Node.ID, projectID, d.Update); err != nil {
+		return err
+	}
+	if d.Stop {
+		return startNode(d.Node.ID, projectID)
+	}
+	return nil
+}
+
+// updateNodeProperties PUTs changed properties to a node. console_type is a
+// node attribute in the GNS3 API, the rest are node properties.
+func updateNodeProperties(nodeID, projectID string, props map[string]interface{}) error {
+	payload := map[string]interface{}{}
+	rest := map[string]interface{}{}
+	for k, v := range props {
+		if k == "console_type" {
+			payload[k] = v
+			continue
+		}
+		rest[k] = v
+	}
+	if len(rest) > 0 {
+		payload["properties"] = rest
+	}
+	url := fmt.Sprintf("%s/v2/projects/%s/nodes/%s", strings.TrimRight(gns3Server, "/"), projectID, nodeID)
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("update node PUT failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("update node API %d: %s", resp.StatusCode, data)
+	}
+	return nil
+}
+
+func stopNode(nodeID, projectID string) error {
+	url := fmt.Sprintf("%s/v2/projects/%s/nodes/%s/stop", strings.TrimRight(gns3Server, "/"), projectID, nodeID)
+	resp, err := http.Post(url, "application/json", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("stop API %d: %s", resp.StatusCode, data)
+	}
+	return nil
+}
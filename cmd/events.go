@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var (
+	eventsFollow bool
+	eventsJSON   bool
+	eventsErrors bool
+	eventsNode   string
+	eventsAction string
+	eventsPass   string
+	eventsSince  string
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Show the reconcile daemon's audit log",
+	Long: `Print the reconcile daemon's audit log, projects/<name>/logs/events.jsonl:
+every pass with its trigger (initial, file, timer, api or webhook), every node and link it
+created, deleted, renamed, updated or started with GNS3's response, every
+Terraform command with its exit status and every router it reconfigured.
+
+  netdevops events -c topology.yaml --node R3 --action node.delete --since 24h
+  netdevops events -c topology.yaml --follow
+
+--action matches a whole action or a family: "node" matches node.create,
+node.delete and so on. --since takes a duration (12h), a date (2024-05-01)
+or an RFC 3339 time.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Only the project name is read, so the log of a daemon that
+		// rejected the topology can still be inspected.
+		name, err := topology.ProjectName(configFile)
+		if err != nil {
+			return err
+		}
+		filter, err := newEventFilter()
+		if err != nil {
+			return err
+		}
+		return tailEvents(auditPath(name), filter, eventsFollow)
+	},
+}
+
+func init() {
+	eventsCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	eventsCmd.Flags().BoolVarP(&eventsFollow, "follow", "f", false, "keep printing events as the daemon records them")
+	eventsCmd.Flags().BoolVar(&eventsJSON, "json", false, "print matching events as JSON lines")
+	eventsCmd.Flags().BoolVar(&eventsErrors, "errors", false, "only show failed actions")
+	eventsCmd.Flags().StringVar(&eventsNode, "node", "", "only show events of this node, including its links")
+	eventsCmd.Flags().StringVar(&eventsAction, "action", "", "only show this action or action family, e.g. node.delete or link")
+	eventsCmd.Flags().StringVar(&eventsPass, "pass", "", "only show events of this pass ID")
+	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "only show events since a duration ago, a date or an RFC 3339 time")
+	rootCmd.AddCommand(eventsCmd)
+}
+
+// eventFilter selects audit events; zero fields match everything.
+type eventFilter struct {
+	node, action, pass string
+	since              time.Time
+	errors             bool
+}
+
+func newEventFilter() (eventFilter, error) {
+	f := eventFilter{node: eventsNode, action: eventsAction, pass: eventsPass, errors: eventsErrors}
+	if eventsSince == "" {
+		return f, nil
+	}
+	if d, err := time.ParseDuration(eventsSince); err == nil {
+		f.since = time.Now().Add(-d)
+		return f, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, eventsSince, time.Local); err == nil {
+			f.since = t
+			return f, nil
+		}
+	}
+	return f, fmt.Errorf("invalid --since %q: want a duration, a date or an RFC 3339 time", eventsSince)
+}
+
+func (f eventFilter) match(ev auditEvent) bool {
+	if f.node != "" && ev.Node != f.node && !linkHasNode(ev.Link, f.node) {
+		return false
+	}
+	if f.action != "" && ev.Action != f.action && !strings.HasPrefix(ev.Action, f.action+".") {
+		return false
+	}
+	if f.pass != "" && ev.Pass != f.pass {
+		return false
+	}
+	if !f.since.IsZero() && ev.Time.Before(f.since) {
+		return false
+	}
+	return !f.errors || ev.Result != "ok"
+}
+
+// linkHasNode reports whether a link label (R1:1/0--R2:1/0) ends on node.
+func linkHasNode(link, node string) bool {
+	for _, end := range strings.Split(link, "--") {
+		if i := strings.LastIndex(end, ":"); i > 0 && end[:i] == node {
+			return true
+		}
+	}
+	return false
+}
+
+// tailEvents prints the events of path matching f and, with follow, those
+// appended later until interrupted.
+func tailEvents(path string, f eventFilter, follow bool) error {
+	file, err := os.Open(path)
+	for os.IsNotExist(err) && follow {
+		time.Sleep(time.Second)
+		file, err = os.Open(path)
+	}
+	if os.IsNotExist(err) {
+		fmt.Printf("📭 No events recorded yet (%s)\n", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot open event log: %w", err)
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	var partial string
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// Keep a line the daemon is still writing for the next read.
+			partial += line
+			if !follow {
+				return nil
+			}
+			time.Sleep(time.Second)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("error reading event log: %w", err)
+		}
+		line, partial = partial+line, ""
+		var ev auditEvent
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️ skipping invalid event: %s", line)
+			continue
+		}
+		if !f.match(ev) {
+			continue
+		}
+		if eventsJSON {
+			fmt.Print(line)
+		} else {
+			printEvent(ev)
+		}
+	}
+}
+
+// printEvent renders one event on a line.
+func printEvent(ev auditEvent) {
+	target := ev.Node
+	if ev.Link != "" {
+		target = ev.Link
+	}
+	status := colorGreen + "✅" + colorReset
+	if ev.Result != "ok" {
+		status = colorRed + "❌" + colorReset
+	}
+	var notes []string
+	if ev.Command != "" && ev.Exit != nil {
+		notes = append(notes, fmt.Sprintf("`%s` exit %d", ev.Command, *ev.Exit))
+	}
+	if ev.Detail != "" {
+		notes = append(notes, ev.Detail)
+	}
+	if ev.ID != "" {
+		notes = append(notes, "id "+ev.ID)
+	}
+	if ev.Error != "" {
+		// Command output spans lines; keep one event per line.
+		notes = append(notes, colorRed+strings.Join(strings.Fields(ev.Error), " ")+colorReset)
+	}
+	fmt.Printf("%s  %s  %-7s  %s %-18s %-24s %s\n",
+		ev.Time.Local().Format("2006-01-02 15:04:05"), ev.Pass, ev.Trigger,
+		status, ev.Action, target, strings.Join(notes, "; "))
+}
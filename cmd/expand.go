@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var expandOutput string
+
+var expandCmd = &cobra.Command{
+	Use:   "expand",
+	Short: "Print the topology as every command sees it, with the fabric expanded",
+	Long: `Print the fully resolved topology: the fabric block expanded into routers
+and links, defaults filled in, derived MAC addresses and IPAM assignments
+applied. The output is a plain topology file that needs none of them.
+
+  netdevops expand -c fabric.yaml -o topology.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		topo, err := loadTopologyReadOnly(configFile)
+		if err != nil {
+			return err
+		}
+		data, err := topo.Marshal()
+		if err != nil {
+			return fmt.Errorf("could not render topology: %w", err)
+		}
+		if expandOutput == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(expandOutput, data, 0644); err != nil {
+			return fmt.Errorf("could not write topology to %s: %w", expandOutput, err)
+		}
+		fmt.Println("✅ Expanded topology written to", expandOutput)
+		return nil
+	},
+}
+
+func init() {
+	expandCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	expandCmd.Flags().StringVarP(&expandOutput, "output", "o", "", "write the topology to this file instead of stdout")
+	rootCmd.AddCommand(expandCmd)
+}
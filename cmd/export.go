@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var (
+	exportOutput string
+	exportKinds  map[string]string
+	exportImages map[string]string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Translate the topology into another lab tool's format",
+}
+
+var exportClabCmd = &cobra.Command{
+	Use:   "clab",
+	Short: "Write the topology as a containerlab .clab.yml file",
+	Long: `Translate the topology into a containerlab topology so the same source of
+truth can run on containerlab. Routers become nodes of their vendor's kind
+(arista_ceos, juniper_crpd, cisco_csr1000v), docker nodes linux nodes,
+switches and hubs bridges, and adapter N becomes ethN. Router disk images are
+not container images; give the image per vendor with --image.
+
+  netdevops export clab -c topology.yaml --image arista=ceos:4.32.0F -o lab.clab.yml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		topo, err := loadTopologyReadOnly(configFile)
+		if err != nil {
+			return err
+		}
+		data, notes, err := topology.ExportClab(topo, exportKinds, exportImages)
+		if err != nil {
+			return fmt.Errorf("could not render containerlab topology: %w", err)
+		}
+		for _, n := range notes {
+			fmt.Fprintf(os.Stderr, "%s⚠️  %s%s\n", colorYellow, n, colorReset)
+		}
+		if exportOutput == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(exportOutput, data, 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", exportOutput, err)
+		}
+		fmt.Fprintln(os.Stderr, "✅ containerlab topology written to", exportOutput)
+		return nil
+	},
+}
+
+func init() {
+	exportCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	exportCmd.PersistentFlags().StringVarP(&exportOutput, "output", "o", "", "write to this file instead of stdout")
+	exportClabCmd.Flags().StringToStringVar(&exportKinds, "kind", nil, "containerlab kind per vendor, e.g. cisco=cisco_iol")
+	exportClabCmd.Flags().StringToStringVar(&exportImages, "image", nil, "container image per vendor, e.g. arista=ceos:4.32.0F")
+	exportCmd.AddCommand(exportClabCmd)
+	rootCmd.AddCommand(exportCmd)
+}
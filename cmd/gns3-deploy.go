@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
@@ -17,7 +16,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var gns3DeployCmd = &cobra.Command{
@@ -29,33 +27,42 @@ var gns3DeployCmd = &cobra.Command{
 func init() {
 	gns3DeployCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
 	gns3DeployCmd.Flags().BoolVarP(&detach, "detach", "d", false, "run in background (daemonize)")
+	gns3DeployCmd.Flags().BoolVar(&reconcileOpts.DryRun, "dry-run", false, "reconcile daemon only logs its plan instead of changing GNS3")
+	gns3DeployCmd.Flags().DurationVar(&reconcileOpts.Interval, "interval", 0, "time between periodic reconcile passes (default project.reconcile.interval or 30s)")
+	gns3DeployCmd.Flags().DurationVar(&reconcileOpts.Jitter, "jitter", 0, "random extra delay up to this long per reconcile pass")
+	gns3DeployCmd.Flags().DurationVar(&reconcileOpts.Debounce, "debounce", 0, "quiet time after a topology file change before reconciling (default 2s)")
+	gns3DeployCmd.Flags().DurationVar(&reconcileOpts.MaxBackoff, "max-backoff", 0, "cap of the retry delay after failed passes (default 10m)")
+	gns3DeployCmd.Flags().IntVar(&reconcileOpts.MaxFailures, "max-failures", 0, "stop the reconcile daemon after this many consecutive failed passes (0: never)")
+	gns3DeployCmd.Flags().BoolVar(&reconcileOpts.NoHeal, "no-heal", false, "do not re-run the configuration playbook on routers the reconcile daemon recreates")
+	gns3DeployCmd.Flags().StringVar(&reconcileOpts.MetricsAddr, "metrics-addr", "", "serve the reconcile daemon's Prometheus metrics on this address, e.g. :9469")
+	gns3DeployCmd.Flags().StringVar(&reconcileOpts.WebhookAddr, "webhook-addr", "", "accept Git push webhooks for the reconcile daemon on this address, e.g. :9470 (secret in $"+webhookSecretEnv+")")
+	gns3DeployCmd.Flags().BoolVar(&reconcileOpts.WebhookInsecure, "webhook-insecure", false, "accept unauthenticated Git webhooks when $"+webhookSecretEnv+" is not set")
 	rootCmd.AddCommand(gns3DeployCmd)
 }
 
 func runGNS3Deploy(cmd *cobra.Command, args []string) error {
+	// Refuse an unauthenticated webhook before deploying anything.
+	if reconcileOpts.WebhookAddr != "" {
+		if err := checkWebhookSecret(reconcileOpts.WebhookInsecure); err != nil {
+			return err
+		}
+	}
+
 	// 1) Read & parse topology
 	fmt.Println("📂 Reading YAML topology...")
-	data, err := ioutil.ReadFile(configFile)
+	topo, err := loadTopology(configFile)
 	if err != nil {
-		return fmt.Errorf("error reading YAML file %q: %w", configFile, err)
-	}
-	var topo Topology
-	if err := yaml.Unmarshal(data, &topo); err != nil {
-		prettyYAMLErrors(err)
-		return fmt.Errorf("invalid YAML: %w", err)
+		return fmt.Errorf("invalid topology: %w", err)
 	}
 
 	// 2) Validate
-	// if err := validateTopology(&topo); err != nil {
-	// 	fmt.Println("❌ Validation failed:")
-	// 	fmt.Println(err)
-	// 	return err
-	// }
-
-	// 3) GNS3 server must be set
-	if topo.Project.GNS3Server == "" {
-		return fmt.Errorf("project.gns3_server must be set in your YAML")
+	if err := validateTopology(&topo); err != nil {
+		fmt.Println("❌ Validation failed:")
+		fmt.Println(err)
+		return err
 	}
+
+	// 3) GNS3 server (defaulted by the loader when omitted)
 	gns3Server = topo.Project.GNS3Server
 
 	// 4) Create project directories
@@ -87,20 +94,7 @@ func runGNS3Deploy(cmd *cobra.Command, args []string) error {
 	// 6) Generate Terraform
 	fmt.Println("⚙️ Generating Terraform configuration from YAML...")
 	tfMain := filepath.Join(tfDir, "main.tf")
-	ctx := struct {
-		Topology            *Topology
-		QemuRouters         []NetworkDevice
-		TemplateRouters     []Router
-		TemplateServers     []TemplateServer
-		UniqueTemplateNames map[string]bool
-	}{
-		Topology:            &topo,
-		QemuRouters:         topo.NetworkDevice.Routers,
-		TemplateRouters:     topo.Templates.Routers,
-		TemplateServers:     topo.Templates.Servers,
-		UniqueTemplateNames: UniqueTemplateNames(topo.Templates),
-	}
-	if err := generateTerraformFile(tfMain, terraformTemplate, ctx); err != nil {
+	if err := writeTerraformConfig(topo, tfMain); err != nil {
 		return fmt.Errorf("error generating Terraform file: %w", err)
 	}
 
@@ -137,7 +131,7 @@ func runGNS3Deploy(cmd *cobra.Command, args []string) error {
 		if srv.ZTPServer != "" {
 			endpoint := fmt.Sprintf("http://%s:5000/upload-yaml", srv.ZTPServer)
 			fmt.Printf("🚀 Uploading topology YAML to %s\n", endpoint)
-			if err := uploadTopologyUntilSuccess(configFile, endpoint); err != nil {
+			if err := uploadTopologyUntilSuccess(topo, filepath.Base(configFile), endpoint); err != nil {
 				fmt.Println("❌ Upload to ZTP failed. See log for details.")
 				return err
 			}
@@ -166,15 +160,86 @@ func runGNS3Deploy(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔁 Starting reconciliation daemon…")
 	if detach {
 		fmt.Printf("🔁 Detaching reconciliation daemon to background, logs at:\n    %s\n", logFile)
-		return forkReconcileDaemon(configFile, projectID, logFile)
+		fmt.Printf("🎛️  Check on it with: netdevops daemon status -c %s\n", configFile)
+		return forkReconcileDaemon(configFile, projectID, logFile, reconcileOpts)
 	} else {
-		StartReconcileDaemon(configFile, projectID)
+		StartReconcileDaemon(configFile, projectID, reconcileOpts)
 	}
 
 	return nil
 }
 
-func forkReconcileDaemon(configFile, projectID, logFile string) error {
+// writeTerraformConfig renders the Terraform configuration of topo to path.
+func writeTerraformConfig(topo Topology, path string) error {
+	// IOU and Dynamips nodes without an image are plain template nodes
+	// here; the reconciler applies the properties they override.
+	templateNodes := topo.TemplateNodes()
+	var iouNodes []IOUNode
+	var dynamipsNodes []DynamipsNode
+	for _, n := range topo.IOU {
+		if n.Image != "" {
+			iouNodes = append(iouNodes, n)
+			continue
+		}
+		templateNodes = append(templateNodes, TemplateNode{Name: n.Name, TemplateName: n.TemplateName, Kind: "iou", Position: n.Position})
+	}
+	for _, n := range topo.Dynamips {
+		if n.Image != "" {
+			dynamipsNodes = append(dynamipsNodes, n)
+			continue
+		}
+		templateNodes = append(templateNodes, TemplateNode{Name: n.Name, TemplateName: n.TemplateName, Kind: "dynamips", Position: n.Position})
+	}
+	templateNames := UniqueTemplateNames(topo.Templates)
+	for _, n := range templateNodes {
+		templateNames[n.TemplateName] = true
+	}
+	ctx := struct {
+		Topology            *Topology
+		QemuRouters         []NetworkDevice
+		TemplateRouters     []Router
+		TemplateServers     []TemplateServer
+		TemplateNodes       []TemplateNode
+		IOUNodes            []IOUNode
+		DynamipsNodes       []DynamipsNode
+		UniqueTemplateNames map[string]bool
+		LinkMoves           []linkMove
+	}{
+		Topology:            &topo,
+		QemuRouters:         topo.NetworkDevice.Routers,
+		TemplateRouters:     topo.Templates.Routers,
+		TemplateServers:     topo.Templates.Servers,
+		TemplateNodes:       templateNodes,
+		IOUNodes:            iouNodes,
+		DynamipsNodes:       dynamipsNodes,
+		UniqueTemplateNames: templateNames,
+		LinkMoves:           legacyLinkMoves(topo),
+	}
+	return generateTerraformFile(path, terraformTemplate, ctx)
+}
+
+// linkMove renames a gns3_link in Terraform state.
+type linkMove struct{ From, To string }
+
+// legacyLinkMoves moves links deployed under the old <A>_to_<B> names to
+// their port-qualified names, so upgrading does not recreate every link.
+// Node pairs with parallel links had colliding old names and are skipped.
+func legacyLinkMoves(topo Topology) []linkMove {
+	count := make(map[string]int)
+	for _, l := range topo.Links {
+		count[l.Endpoints[0].Name+"_to_"+l.Endpoints[1].Name]++
+	}
+	var moves []linkMove
+	for _, l := range topo.Links {
+		old := l.Endpoints[0].Name + "_to_" + l.Endpoints[1].Name
+		if count[old] == 1 {
+			moves = append(moves, linkMove{From: old, To: l.ResourceName()})
+		}
+	}
+	return moves
+}
+
+func forkReconcileDaemon(configFile, projectID, logFile string, opts ReconcileOptions) error {
 	f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
 		return fmt.Errorf("cannot open log file %s: %w", logFile, err)
@@ -184,6 +249,7 @@ func forkReconcileDaemon(configFile, projectID, logFile string) error {
 	// 🔥 NOTE: Use __reconcile_daemon as the first argument!
 	exe := os.Args[0]
 	args := []string{exe, "__reconcile_daemon", "--config", configFile, "--project-id", projectID}
+	args = append(args, opts.args()...)
 	attrs := &syscall.ProcAttr{
 		Files: []uintptr{devNull.Fd(), f.Fd(), f.Fd()},
 		Env:   os.Environ(),
@@ -245,24 +311,23 @@ func formatAndSaveTerraformOutputs(dir, outputFile string) error {
 	return nil
 }
 
-func uploadTopologyUntilSuccess(filePath, endpoint string) error {
+// uploadTopologyUntilSuccess posts the resolved topology to the ZTP server,
+// so it sees derived MAC addresses and IPAM assignments, not the raw file.
+func uploadTopologyUntilSuccess(topo Topology, fileName, endpoint string) error {
+	data, err := topo.Marshal()
+	if err != nil {
+		return fmt.Errorf("marshal topology: %w", err)
+	}
 	for {
-		f, err := os.Open(filePath)
-		if err != nil {
-			return fmt.Errorf("open file: %w", err)
-		}
 		var buf bytes.Buffer
 		w := multipart.NewWriter(&buf)
-		part, err := w.CreateFormFile("file", filePath)
+		part, err := w.CreateFormFile("file", fileName)
 		if err != nil {
-			f.Close()
 			return fmt.Errorf("create form file: %w", err)
 		}
-		if _, err := io.Copy(part, f); err != nil {
-			f.Close()
+		if _, err := part.Write(data); err != nil {
 			return fmt.Errorf("copy file: %w", err)
 		}
-		f.Close()
 		w.Close()
 
 		req, err := http.NewRequest("POST", endpoint, &buf)
@@ -411,6 +476,15 @@ func writeInventoryFromZTPMap(raw map[string]interface{}, osType, ansDir string)
 	}
 	return nil
 }
+
+// orTemplate names what an IOU or Dynamips node is built from.
+func orTemplate(image, template string) string {
+	if image != "" {
+		return image
+	}
+	return "template " + template
+}
+
 func visualizeTopology(t Topology) {
 	fmt.Println("🖥️ Routers:")
 	for _, r := range t.NetworkDevice.Routers {
@@ -428,6 +502,27 @@ func visualizeTopology(t Topology) {
 			fmt.Printf("🌥️ [ %s ]\n", c.Name)
 		}
 	}
+	if len(t.Docker) > 0 {
+		fmt.Println("\n🐳 Docker hosts:")
+		for _, d := range t.Docker {
+			fmt.Printf("🐳 [ %s ] %s\n", d.Name, d.Image)
+		}
+	}
+	if nodes := t.TemplateNodes(); len(nodes) > 0 {
+		fmt.Println("\n🧩 Template nodes:")
+		for _, n := range nodes {
+			fmt.Printf("🧩 [ %s ] %s (%s)\n", n.Name, n.Kind, n.TemplateName)
+		}
+	}
+	if len(t.IOU) > 0 || len(t.Dynamips) > 0 {
+		fmt.Println("\n📟 IOU and Dynamips:")
+		for _, n := range t.IOU {
+			fmt.Printf("📟 [ %s ] iou (%s)\n", n.Name, orTemplate(n.Image, n.TemplateName))
+		}
+		for _, n := range t.Dynamips {
+			fmt.Printf("📟 [ %s ] dynamips %s (%s)\n", n.Name, n.Platform, orTemplate(n.Image, n.TemplateName))
+		}
+	}
 	if len(t.Links) > 0 {
 		fmt.Println("\n🔗 Links:")
 		for _, l := range t.Links {
@@ -2,14 +2,12 @@ package cmd
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -22,14 +20,9 @@ var gns3DestroyCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		// 1) Read & parse topology YAML
 		fmt.Println("📂 Reading YAML topology for destroy...")
-		data, err := ioutil.ReadFile(configFile)
+		topology, err := loadTopology(configFile)
 		if err != nil {
-			fmt.Println("❌ Error reading YAML file:", err)
-			os.Exit(1)
-		}
-		var topology Topology
-		if err := yaml.Unmarshal(data, &topology); err != nil {
-			fmt.Println("❌ Error parsing YAML:", err)
+			fmt.Println("❌ Error loading YAML topology:", err)
 			os.Exit(1)
 		}
 
@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 )
 
 // PingTestVars is the context we pass into the ping‐through playbook.
@@ -23,37 +22,22 @@ type PingTestVars struct {
 	TargetIP string
 }
 
+// validateFile is the -c of gns3-validate, which has no default.
+var validateFile string
+
 var gns3ValidateCmd = &cobra.Command{
 	Use:   "gns3-validate",
 	Short: "Test connectivity across your GNS3 routers and upload the inventory",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if configFile == "" {
+		if validateFile == "" {
 			return fmt.Errorf("deployment YAML must be provided with --config")
 		}
-		data, err := os.ReadFile(configFile)
+		topo, err := loadTopology(validateFile)
 		if err != nil {
-			return fmt.Errorf("reading %s: %w", configFile, err)
-		}
-
-		// Mirror your schema: routers under network-device, servers under templates
-		var topo struct {
-			NetworkDevice struct {
-				Routers []NetworkDevice `yaml:"routers"`
-			} `yaml:"network-device"`
-			Templates struct {
-				Servers []struct {
-					Name         string `yaml:"name"`
-					ZTPServer    string `yaml:"ztp_server,omitempty"`
-					ObserveTower string `yaml:"observe-tower,omitempty"`
-					Start        bool   `yaml:"start"`
-				} `yaml:"servers"`
-			} `yaml:"templates"`
-		}
-		if err := yaml.Unmarshal(data, &topo); err != nil {
-			return fmt.Errorf("parsing %s: %w", configFile, err)
+			return fmt.Errorf("loading %s: %w", validateFile, err)
 		}
 
-		routers := topo.NetworkDevice.Routers
+		routers := topo.ConfiguredRouters()
 		if len(routers) < 2 {
 			return fmt.Errorf("need at least two routers to validate, got %d", len(routers))
 		}
@@ -64,16 +48,10 @@ var gns3ValidateCmd = &cobra.Command{
 
 		// extract its first IP
 		var targetIP string
-		if cfgs, ok := last.Config.([]interface{}); ok {
-			for _, raw := range cfgs {
-				if m, ok := raw.(map[interface{}]interface{}); ok {
-					if ipRaw, found := m["ip_address"]; found {
-						if s, ok := ipRaw.(string); ok {
-							targetIP = strings.Split(s, "/")[0]
-							break
-						}
-					}
-				}
+		for _, cfg := range last.Config {
+			if cfg.IPAddress != "" {
+				targetIP = strings.Split(cfg.IPAddress, "/")[0]
+				break
 			}
 		}
 		if targetIP == "" {
@@ -191,6 +169,6 @@ func uploadInventory(path, obsIP string) error {
 
 func init() {
 	rootCmd.AddCommand(gns3ValidateCmd)
-	gns3ValidateCmd.Flags().StringVarP(&configFile, "config", "c", "", "Deployment YAML file")
+	gns3ValidateCmd.Flags().StringVarP(&validateFile, "config", "c", "", "Deployment YAML file")
 	gns3ValidateCmd.Flags().StringVar(&inventoryFile, "inventory", "ansible-inventory/inventory.yaml", "Inventory file")
 }
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// healTimeout is how long a router the reconciler created may take to boot
+// and answer SSH before healing it is given up.
+const healTimeout = 10 * time.Minute
+
+// configHealer re-applies the gns3-configure playbook to routers the
+// reconciler created or recreated, which boot with a blank configuration.
+// Routers are healed one at a time in the background, so reconcile passes
+// go on while they boot.
+type configHealer struct {
+	mu      sync.Mutex
+	pending map[string]bool // routers queued or being healed
+	jobs    chan healJob
+}
+
+type healJob struct {
+	topo          Topology
+	router        Router
+	pass, trigger string // the reconcile pass that created the router
+}
+
+func newConfigHealer() *configHealer {
+	h := &configHealer{pending: make(map[string]bool), jobs: make(chan healJob, 64)}
+	go h.run()
+	return h
+}
+
+// heal queues the configured routers among names. Routers already queued
+// are skipped.
+func (h *configHealer) heal(topo Topology, names []string) {
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		want[n] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range topo.ConfiguredRouters() {
+		if !want[r.Name] || h.pending[r.Name] || len(r.Config) == 0 {
+			continue
+		}
+		pass, trigger := audit.currentPass()
+		select {
+		case h.jobs <- healJob{topo: topo, router: r, pass: pass, trigger: trigger}:
+			h.pending[r.Name] = true
+			fmt.Printf("🩹 Queued configuration of %s\n", r.Name)
+		default:
+			fmt.Fprintf(os.Stderr, "⚠️ Heal queue full; %s is not reconfigured\n", r.Name)
+		}
+	}
+}
+
+func (h *configHealer) run() {
+	for job := range h.jobs {
+		name := job.router.Name
+		err := healRouter(job.topo, job.router)
+		audit.record(auditEvent{Pass: job.pass, Trigger: job.trigger, Action: "node.heal", Node: name}, err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Could not heal %s: %v\n", name, err)
+		} else {
+			fmt.Printf("✅ Configuration restored on %s\n", name)
+		}
+		h.mu.Lock()
+		delete(h.pending, name)
+		h.mu.Unlock()
+	}
+}
+
+// healRouter waits for a new router to become reachable, refreshes the
+// Ansible inventory from the ZTP server and runs the router's playbook.
+func healRouter(topo Topology, router Router) error {
+	pbData := buildPlaybookData(router)
+	if len(pbData.IPConfigs) == 0 {
+		fmt.Printf("⚠️  %s has no usable IP configuration; nothing to heal\n", router.Name)
+		return nil
+	}
+
+	ansDir := filepath.Join("projects", topo.Project.Name, "ansible")
+	fmt.Printf("🩹 Refreshing inventory for %s…\n", router.Name)
+	if err := generateInventoryFromYAML(topo, ansDir); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ inventory refresh failed, using the existing one: %v\n", err)
+	}
+	inventory := filepath.Join(ansDir, "inventory.yml")
+	host, err := inventoryHost(inventory, router.Name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🩹 Waiting for %s at %s:22…\n", router.Name, host)
+	if err := waitReachable(net.JoinHostPort(host, "22"), healTimeout); err != nil {
+		return err
+	}
+
+	tmpl, err := newPlaybookTemplate()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🩹 Running configuration playbook on %s…\n", router.Name)
+	output, err := runPlaybook(tmpl, pbData, inventory)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, output)
+		return fmt.Errorf("playbook failed: %w", err)
+	}
+	return nil
+}
+
+// inventoryHost returns the ansible_host of a router in an inventory
+// written by generateInventoryFromYAML.
+func inventoryHost(path, name string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no inventory: %w", err)
+	}
+	var inv struct {
+		All struct {
+			Hosts map[string]struct {
+				AnsibleHost string `yaml:"ansible_host"`
+			} `yaml:"hosts"`
+		} `yaml:"all"`
+	}
+	if err := yaml.Unmarshal(data, &inv); err != nil {
+		return "", fmt.Errorf("invalid inventory %s: %w", path, err)
+	}
+	h, ok := inv.All.Hosts[name]
+	if !ok || h.AnsibleHost == "" {
+		return "", fmt.Errorf("%s is not in inventory %s", name, path)
+	}
+	return h.AnsibleHost, nil
+}
+
+// waitReachable polls a TCP address until it accepts a connection.
+func waitReachable(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s not reachable after %s", addr, timeout)
+		}
+		time.Sleep(5 * time.Second)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var (
+	importOutput           string
+	importTerraformVersion string
+	importGNS3Server       string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create a topology file from an existing lab",
+}
+
+var importGNS3Cmd = &cobra.Command{
+	Use:   "gns3 <file.gns3>",
+	Short: "Create a topology file from a GNS3 project file",
+	Long: `Read a .gns3 project file (nodes, node types, properties, links and canvas
+positions) and write the equivalent topology file, so a hand-built lab can be
+deployed and reconciled by netdevops. Guesses the importer has to make, such
+as a router's vendor, are reported.
+
+  netdevops import gns3 ~/GNS3/projects/lab/lab.gns3 -o topology.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("error reading GNS3 project %q: %w", args[0], err)
+		}
+		topo, notes, err := topology.ImportGNS3(data)
+		if err != nil {
+			return fmt.Errorf("cannot import %s: %w", args[0], err)
+		}
+		return writeImported(topo, notes)
+	},
+}
+
+var importClabCmd = &cobra.Command{
+	Use:   "clab <file.clab.yml>",
+	Short: "Create a topology file from a containerlab topology",
+	Long: `Read a containerlab topology (nodes, kinds, images, links as node:ethX) and
+write the equivalent topology file. Router kinds map to vendors, linux nodes
+to docker, bridges to switches and ethN interfaces to adapter N. Router
+images are container images and have to be pointed at the disk images GNS3
+runs; every such guess is reported.
+
+  netdevops import clab lab.clab.yml -o topology.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("error reading containerlab topology %q: %w", args[0], err)
+		}
+		topo, notes, err := topology.ImportClab(data)
+		if err != nil {
+			return fmt.Errorf("cannot import %s: %w", args[0], err)
+		}
+		return writeImported(topo, notes)
+	},
+}
+
+// writeImported fills in the project settings given on the command line,
+// reports the importer's notes and writes the topology.
+func writeImported(topo Topology, notes []string) error {
+	topo.Project.TerraformVersion = importTerraformVersion
+	topo.Project.GNS3Server = importGNS3Server
+	topo.Project.StartNodes = true
+	for _, n := range notes {
+		fmt.Fprintf(os.Stderr, "%s⚠️  %s%s\n", colorYellow, n, colorReset)
+	}
+	data, err := topo.Marshal()
+	if err != nil {
+		return fmt.Errorf("could not render topology: %w", err)
+	}
+	if importOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(importOutput, data, 0644); err != nil {
+		return fmt.Errorf("could not write %s: %w", importOutput, err)
+	}
+	fmt.Fprintln(os.Stderr, "✅ Topology written to", importOutput)
+	return nil
+}
+
+func init() {
+	importCmd.PersistentFlags().StringVarP(&importOutput, "output", "o", "", "write the topology to this file instead of stdout")
+	importCmd.PersistentFlags().StringVar(&importTerraformVersion, "terraform-version", "2.5.3", "netopschic/gns3 provider version to record in the project")
+	importCmd.PersistentFlags().StringVar(&importGNS3Server, "gns3-server", topology.DefaultGNS3Server, "GNS3 server URL to record in the project")
+	importCmd.AddCommand(importGNS3Cmd, importClabCmd)
+	rootCmd.AddCommand(importCmd)
+}
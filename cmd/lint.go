@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint [file...]",
+	Short: "Validate topology YAML files against the topology JSON Schema",
+	Long: `Validate one or more topology files against the embedded JSON Schema and
+report every violation with its YAML line and column. Files that pass the
+schema are then checked semantically (duplicate names, dangling or
+double-cabled links, MAC addresses, subnets). With no arguments the file
+given by --config is checked.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files := args
+		if len(files) == 0 {
+			files = []string{configFile}
+		}
+
+		var failed int
+		for _, f := range files {
+			n, err := lintFile(f)
+			if err != nil {
+				return err
+			}
+			failed += n
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d error(s) found", failed)
+		}
+		return nil
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	rootCmd.AddCommand(lintCmd)
+}
+
+// lintFile prints the schema diagnostics, or the semantic errors of a file
+// that passes the schema, for a single file and returns how many it found.
+func lintFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("error reading YAML file %q: %w", path, err)
+	}
+	if err := topology.CheckVersion(data); err != nil {
+		var verr *topology.VersionError
+		if errors.As(err, &verr) {
+			fmt.Printf("%s❌ %s: %v%s\n", colorRed, path, err, colorReset)
+			return 1, nil
+		}
+	}
+	diags, err := topology.ValidateSchema(data)
+	if err != nil {
+		prettyYAMLErrors(err)
+		return 1, nil
+	}
+	if len(diags) == 0 {
+		// Number IPAM addresses from the lockfile as deploy will, without
+		// recording new assignments.
+		topo, err := topology.LoadReadOnly(path)
+		if err != nil {
+			prettyYAMLErrors(err)
+			return 1, nil
+		}
+		if err := validateTopology(&topo); err != nil {
+			var verr *validationError
+			if !errors.As(err, &verr) {
+				fmt.Printf("%s❌ %s: %v%s\n", colorRed, path, err, colorReset)
+				return 1, nil
+			}
+			fmt.Printf("%s❌ %s has %d semantic error(s):%s\n", colorRed, path, len(verr.Errs), colorReset)
+			for _, e := range verr.Errs {
+				fmt.Printf("  %s•%s %s%s: %s%s\n", colorCyan, colorReset, colorYellow, path, e, colorReset)
+			}
+			return len(verr.Errs), nil
+		}
+		fmt.Printf("✅ %s is valid\n", path)
+		return 0, nil
+	}
+	fmt.Printf("%s❌ %s has %d schema error(s):%s\n", colorRed, path, len(diags), colorReset)
+	for _, d := range diags {
+		fmt.Printf("  %s•%s %s%s:%s%s\n", colorCyan, colorReset, colorYellow, path, d, colorReset)
+	}
+	return len(diags), nil
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// reconcileMetrics are the reconcile daemon's Prometheus metrics, served
+// in the text exposition format on --metrics-addr. A nil *reconcileMetrics
+// records nothing.
+type reconcileMetrics struct {
+	mu sync.Mutex
+
+	passes     *counterVec
+	aborted    *counterVec
+	rejected   *counterVec
+	nodes      *counterVec
+	links      *counterVec
+	drift      *counterVec
+	apiErrors  *counterVec
+	tfFailures *counterVec
+	duration   *histogram
+
+	lastPass, lastSuccess float64 // Unix time
+	failures              int     // consecutive failed passes
+}
+
+// metrics is the daemon's metrics; nil unless --metrics-addr is set.
+var metrics *reconcileMetrics
+
+func newReconcileMetrics() *reconcileMetrics {
+	return &reconcileMetrics{
+		passes:     newCounterVec("netdevops_reconcile_passes_total", "Reconcile passes by trigger and result.", "trigger", "result"),
+		aborted:    newCounterVec("netdevops_reconcile_aborted_total", "Passes aborted because they exceeded prune.max_deletions."),
+		rejected:   newCounterVec("netdevops_topology_rejected_total", "Topology reloads rejected as invalid."),
+		nodes:      newCounterVec("netdevops_reconcile_nodes_total", "Node actions that succeeded, by action.", "action"),
+		links:      newCounterVec("netdevops_reconcile_links_total", "Link actions that succeeded, by action.", "action"),
+		drift:      newCounterVec("netdevops_reconcile_drift_total", "Drifted nodes detected, by the action taken.", "action"),
+		apiErrors:  newCounterVec("netdevops_gns3_api_errors_total", "Failed GNS3 API calls by endpoint.", "endpoint"),
+		tfFailures: newCounterVec("netdevops_terraform_failures_total", "Failed Terraform commands.", "command"),
+		duration: newHistogram("netdevops_reconcile_pass_duration_seconds", "Duration of reconcile passes.",
+			[]float64{0.5, 1, 2, 5, 10, 30, 60, 120, 300}),
+	}
+}
+
+// gns3Endpoints maps audit actions to the GNS3 API call behind them.
+var gns3Endpoints = map[string]string{
+	"node.create": "POST /nodes",
+	"node.delete": "DELETE /nodes/{id}",
+	"node.start":  "POST /nodes/{id}/start",
+	"node.rename": "PUT /nodes/{id}",
+	"node.update": "PUT /nodes/{id}",
+	"link.create": "POST /links",
+	"link.delete": "DELETE /links/{id}",
+}
+
+// observe counts an audited action and its outcome.
+func (m *reconcileMetrics) observe(ev auditEvent, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kind, action, _ := strings.Cut(ev.Action, ".")
+	switch {
+	case ev.Action == "pass.abort":
+		m.aborted.inc()
+	case ev.Action == "topology.rejected":
+		m.rejected.inc()
+	case kind == "terraform":
+		if err != nil {
+			m.tfFailures.inc(action)
+		}
+	case err != nil:
+		if ep, ok := gns3Endpoints[ev.Action]; ok {
+			m.apiErrors.inc(ep)
+		}
+	case kind == "node":
+		m.nodes.inc(action)
+	case kind == "link":
+		m.links.inc(action)
+	}
+}
+
+// apiError counts a failed GNS3 call that is not an audited action, such as
+// listing nodes.
+func (m *reconcileMetrics) apiError(endpoint string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apiErrors.inc(endpoint)
+}
+
+// driftDetected counts a drifted node and the policy applied to it.
+func (m *reconcileMetrics) driftDetected(action string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drift.inc(action)
+}
+
+// pass records a finished pass.
+func (m *reconcileMetrics) pass(trigger string, start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	m.duration.observe(now.Sub(start).Seconds())
+	m.lastPass = float64(now.Unix())
+	result := "ok"
+	if err != nil {
+		result = "error"
+		m.failures++
+	} else {
+		m.lastSuccess = m.lastPass
+		m.failures = 0
+	}
+	m.passes.inc(trigger, result)
+}
+
+// serve starts the /metrics listener in the background.
+func (m *reconcileMetrics) serve(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("metrics listener: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.write(w)
+	})
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ metrics listener: %v\n", err)
+		}
+	}()
+	fmt.Printf("📈 Serving metrics on http://%s/metrics\n", ln.Addr())
+	return nil
+}
+
+func (m *reconcileMetrics) write(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, c := range []*counterVec{m.passes, m.aborted, m.rejected, m.nodes, m.links, m.drift, m.apiErrors, m.tfFailures} {
+		c.write(w)
+	}
+	m.duration.write(w)
+	writeGauge(w, "netdevops_reconcile_last_pass_timestamp_seconds", "Unix time of the last finished pass.", m.lastPass)
+	writeGauge(w, "netdevops_reconcile_last_success_timestamp_seconds", "Unix time of the last successful pass.", m.lastSuccess)
+	writeGauge(w, "netdevops_reconcile_consecutive_failures", "Failed passes since the last successful one.", float64(m.failures))
+}
+
+// counterVec is a counter with labels; one without labels has one series.
+type counterVec struct {
+	name, help string
+	labels     []string
+	values     map[string]float64 // rendered label set → value
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	c := &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	if len(labels) == 0 {
+		c.values[""] = 0
+	}
+	return c
+}
+
+func (c *counterVec) inc(values ...string) {
+	var pairs []string
+	for i, l := range c.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l, values[i]))
+	}
+	key := ""
+	if len(pairs) > 0 {
+		key = "{" + strings.Join(pairs, ",") + "}"
+	}
+	c.values[key]++
+}
+
+func (c *counterVec) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	keys := make([]string, 0, len(c.values))
+	for k := range c.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s%s %g\n", c.name, k, c.values[k])
+	}
+}
+
+// histogram is a Prometheus histogram with fixed buckets.
+type histogram struct {
+	name, help string
+	buckets    []float64
+	counts     []uint64 // per bucket, not cumulative
+	sum        float64
+	count      uint64
+}
+
+func newHistogram(name, help string, buckets []float64) *histogram {
+	return &histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	var cum uint64
+	for i, b := range h.buckets {
+		cum += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%g\"} %d\n", h.name, b, cum)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.count)
+	fmt.Fprintf(w, "%s_sum %g\n%s_count %d\n", h.name, h.sum, h.name, h.count)
+}
+
+func writeGauge(w io.Writer, name, help string, v float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, v)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var (
+	migrateOutput string
+	migrateWrite  bool
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [file]",
+	Short: "Convert a topology file from an older format to the current one",
+	Long: `Convert a topology file written for an older format version (for example
+the legacy files with project as a plain string, top-level routers keyed by
+template, or config as a single map) to the current layout and stamp it with
+the current version key. Fields that have no equivalent are removed and
+reported. With no argument the file given by --config is migrated.
+
+  netdevops migrate topologies/bgp.yaml -o bgp.yaml
+  netdevops migrate -w topologies/bgp.yaml   # rewrite in place`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := configFile
+		if len(args) == 1 {
+			path = args[0]
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading YAML file %q: %w", path, err)
+		}
+		out, notes, err := topology.Migrate(data)
+		if err != nil {
+			return fmt.Errorf("cannot migrate %s: %w", path, err)
+		}
+		for _, n := range notes {
+			fmt.Fprintf(os.Stderr, "%s⚠️  %s%s\n", colorYellow, n, colorReset)
+		}
+
+		dest := migrateOutput
+		if migrateWrite {
+			dest = path
+		}
+		if dest == "" {
+			_, err := os.Stdout.Write(out)
+			return err
+		}
+		if err := os.WriteFile(dest, out, 0644); err != nil {
+			return fmt.Errorf("could not write %s: %w", dest, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ %s migrated to format version %d → %s\n", path, topology.CurrentVersion, dest)
+		return nil
+	},
+}
+
+func init() {
+	migrateCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	migrateCmd.Flags().StringVarP(&migrateOutput, "output", "o", "", "write the migrated topology to this file instead of stdout")
+	migrateCmd.Flags().BoolVarP(&migrateWrite, "write", "w", false, "rewrite the file in place")
+	rootCmd.AddCommand(migrateCmd)
+}
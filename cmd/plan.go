@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var planProjectID string
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show what the reconciler would change in GNS3, without changing it",
+	Long: `Compare the topology with the nodes and links of the running GNS3 project and
+print exactly what a reconcile pass would do: nodes created, deleted or
+started, property drift and how it would be fixed, links created or deleted,
+and Terraform addresses imported into or removed from state. Nothing is changed in GNS3 or in Terraform state.
+
+  netdevops plan -c topology.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		topo, err := loadTopologyReadOnly(configFile)
+		if err != nil {
+			return err
+		}
+		gns3Server = topo.Project.GNS3Server
+		projectID := planProjectID
+		if projectID == "" {
+			if projectID, err = lookupProjectID(gns3Server, topo.Project.Name); err != nil {
+				return fmt.Errorf("could not find project %q: %w", topo.Project.Name, err)
+			}
+		}
+		plan, err := buildReconcilePlan(topo, projectID)
+		if err != nil {
+			return err
+		}
+		printReconcilePlan(topo.Project.Name, projectID, plan)
+		if max := topo.Project.Prune.MaxDeletions; max > 0 && plan.deletions() > max {
+			fmt.Printf("\n🚨 %d deletions exceed prune.max_deletions (%d): the reconciler would abort this pass\n", plan.deletions(), max)
+		}
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&configFile, "config", "c", "topology.yaml", "YAML topology file")
+	planCmd.Flags().StringVar(&planProjectID, "project-id", "", "GNS3 project ID (default: looked up by project name)")
+	rootCmd.AddCommand(planCmd)
+}
+
+// ReconcilePlan is everything one reconcile pass would do.
+type ReconcilePlan struct {
+	CreateNodes []NodeCreatePayload
+	DeleteNodes []ObservedNode // named by their topology name
+	StartNodes  []ObservedNode
+	RenameNodes []ObservedNode // renamed in GNS3, named by their current name
+	Adopted     []string       // existing nodes that become managed
+	Unmanaged   []ObservedNode // left alone
+	Drift       []nodeDrift    // changed properties and what is done about them
+	KeptNodes   []ObservedNode // not in the topology, kept by the prune policy
+	KeptLinks   []ObservedLink
+	CreateLinks []LinkCreatePayload // endpoints by node name
+	DeleteLinks []ObservedLink
+	// Terraform addresses imported into and removed from state.
+	Imports  []string
+	Removals []string
+
+	names map[string]string // observed node ID → topology name, or GNS3 name if unmanaged
+}
+
+// empty reports whether the pass would change nothing.
+func (p ReconcilePlan) empty() bool {
+	return len(p.CreateNodes)+len(p.DeleteNodes)+len(p.StartNodes)+len(p.RenameNodes)+len(p.Drift)+len(p.CreateLinks)+len(p.DeleteLinks) == 0
+}
+
+// deletions counts the nodes and links the pass would delete, recreated
+// nodes included, for prune.max_deletions.
+func (p ReconcilePlan) deletions() int {
+	n := len(p.DeleteNodes) + len(p.DeleteLinks)
+	for _, d := range p.Drift {
+		if d.Action == driftRecreate {
+			n++
+		}
+	}
+	return n
+}
+
+// buildReconcilePlan diffs the topology against the GNS3 project with the
+// same rules as runReconcile (matchNodes, diffLinks, the prune policy) but
+// only reads; the reconcile state is not saved.
+func buildReconcilePlan(topo Topology, projectID string) (ReconcilePlan, error) {
+	var plan ReconcilePlan
+	desiredNodes, desiredLinksByName := BuildDesired(topo)
+
+	obsNodes, err := fetchNodesFromGNS3(projectID)
+	if err != nil {
+		return plan, fmt.Errorf("fetchNodes: %w", err)
+	}
+	obsLinks, err := fetchLinksFromGNS3(projectID)
+	if err != nil {
+		return plan, fmt.Errorf("fetchLinks: %w", err)
+	}
+	plan.names = make(map[string]string, len(obsNodes))
+	for _, o := range obsNodes {
+		plan.names[o.ID] = o.Name
+	}
+
+	state, err := loadReconcileState(topo.Project.Name, projectID)
+	if err != nil {
+		return plan, err
+	}
+	managed := make(map[string]string, len(state.Nodes)) // ID → topology name
+	for name, id := range state.Nodes {
+		managed[id] = name
+	}
+	prune := newPruner(topo.Project.Prune)
+	m := matchNodes(desiredNodes, obsNodes, state)
+	prune.pruneNodes(&m)
+	prune.observe(obsNodes, state)
+	plan.CreateNodes, plan.Adopted, plan.Unmanaged, plan.KeptNodes = m.ToAdd, m.Adopted, m.Unmanaged, m.Kept
+	for _, o := range m.ToDelete {
+		if name, ok := managed[o.ID]; ok {
+			o.Name = name
+		}
+		plan.DeleteNodes = append(plan.DeleteNodes, o)
+	}
+	plan.Drift = detectDrift(desiredNodes, m, topo.Project.Drift)
+	prune.pruneDrift(plan.Drift)
+	restarted := make(map[string]bool)
+	for _, d := range plan.Drift {
+		restarted[d.Name] = d.Action == driftRecreate || (d.Action == driftUpdate && d.Stop)
+	}
+	for name, o := range m.Matched {
+		plan.names[o.ID] = name
+		if o.Name != name {
+			plan.RenameNodes = append(plan.RenameNodes, o)
+		}
+		if o.Status != "started" && !restarted[name] {
+			plan.StartNodes = append(plan.StartNodes, o)
+		}
+	}
+	sort.Slice(plan.StartNodes, func(i, j int) bool { return plan.StartNodes[i].Name < plan.StartNodes[j].Name })
+
+	// Links between existing nodes are diffed by ID; links to nodes that
+	// do not exist yet are created once the nodes are.
+	nameToID := m.nameToID()
+	deleted := make(map[string]bool, len(plan.DeleteNodes))
+	for _, o := range plan.DeleteNodes {
+		deleted[o.ID] = true
+	}
+	var resolved []LinkCreatePayload
+	byKey := make(map[linkKey]LinkCreatePayload)
+	for _, ln := range desiredLinksByName {
+		rp, ok := resolveLink(ln, nameToID)
+		if !ok {
+			if len(ln.Nodes) == 2 {
+				plan.CreateLinks = append(plan.CreateLinks, ln)
+			}
+			continue
+		}
+		resolved = append(resolved, rp)
+		byKey[rp.key()] = ln
+	}
+	// Links of deleted nodes go with them; the pass never deletes them itself.
+	var remaining []ObservedLink
+	for _, l := range obsLinks {
+		gone := false
+		for _, ep := range l.Nodes {
+			gone = gone || deleted[ep.NodeID]
+		}
+		if !gone {
+			remaining = append(remaining, l)
+		}
+	}
+	toAdd, toDel := diffLinks(resolved, remaining)
+	for _, l := range toAdd {
+		plan.CreateLinks = append(plan.CreateLinks, byKey[l.key()])
+	}
+	plan.DeleteLinks, plan.KeptLinks = prune.pruneLinks(toDel)
+
+	// Terraform state changes, named as runReconcile names them.
+	for _, nd := range plan.CreateNodes {
+		plan.Imports = append(plan.Imports, fmt.Sprintf("%s.%s", nd.ResourceType, nd.Name))
+	}
+	for _, l := range plan.CreateLinks {
+		a, b := l.Nodes[0], l.Nodes[1]
+		plan.Imports = append(plan.Imports, "gns3_link."+topology.LinkResourceName(
+			topology.Endpoint{Name: a.NodeName, Adapter: a.AdapterNumber, Port: a.PortNumber},
+			topology.Endpoint{Name: b.NodeName, Adapter: b.AdapterNumber, Port: b.PortNumber}))
+	}
+	for _, o := range plan.DeleteNodes {
+		if _, ok := managed[o.ID]; ok {
+			plan.Removals = append(plan.Removals, fmt.Sprintf("%s.%s", gns3NodeTypeToTF(o.NodeType), o.Name))
+		}
+	}
+	for _, d := range plan.Drift {
+		if d.Action == driftRecreate {
+			addr := fmt.Sprintf("%s.%s", d.Desired.ResourceType, d.Name)
+			plan.Removals = append(plan.Removals, addr)
+			plan.Imports = append(plan.Imports, addr)
+		}
+	}
+	for _, l := range plan.DeleteLinks {
+		if len(l.Nodes) == 2 && prune.managed[l.Nodes[0].NodeID] && prune.managed[l.Nodes[1].NodeID] {
+			plan.Removals = append(plan.Removals, "gns3_link."+observedLinkResourceName(l, plan.names))
+		}
+	}
+	return plan, nil
+}
+
+// printReconcilePlan prints the plan in the daemon's log style.
+func printReconcilePlan(projectName, projectID string, plan ReconcilePlan) {
+	fmt.Printf("📋 Reconcile plan for project %q (%s)\n", projectName, projectID)
+	if plan.empty() {
+		fmt.Println("✅ Nothing to do: GNS3 matches the topology")
+		return
+	}
+	if len(plan.CreateNodes)+len(plan.DeleteNodes)+len(plan.StartNodes)+len(plan.RenameNodes) > 0 {
+		fmt.Println("\n🖥️ Nodes:")
+		for _, nd := range plan.CreateNodes {
+			fmt.Printf("  ➕ create %s (%s)\n", nd.Name, nd.ResourceType)
+		}
+		for _, o := range plan.DeleteNodes {
+			fmt.Printf("  🗑️  delete %s (%s, %s)\n", o.Name, o.NodeType, o.ID)
+		}
+		for _, o := range plan.StartNodes {
+			fmt.Printf("  🔄 start %s (was %s)\n", o.Name, o.Status)
+		}
+		for _, o := range plan.RenameNodes {
+			fmt.Printf("  🔤 rename %s back to %s\n", o.Name, plan.names[o.ID])
+		}
+	}
+	if len(plan.Drift) > 0 {
+		fmt.Println("\n🧬 Drift:")
+		icons := map[string]string{driftUpdate: "🛠️ ", driftRecreate: "♻️ ", driftWarn: "⚠️ "}
+		for _, d := range plan.Drift {
+			fmt.Printf("  %s %s %s: %s\n", icons[d.Action], d.Action, d.Name, d.describeChanges())
+		}
+	}
+	if len(plan.Adopted) > 0 {
+		fmt.Printf("\n🔗 Existing nodes to manage: %s\n", strings.Join(plan.Adopted, ", "))
+	}
+	if len(plan.Unmanaged) > 0 {
+		var names []string
+		for _, o := range plan.Unmanaged {
+			names = append(names, o.Name)
+		}
+		fmt.Printf("\n👤 Unmanaged nodes left alone: %s\n", strings.Join(names, ", "))
+	}
+	if len(plan.KeptNodes)+len(plan.KeptLinks) > 0 {
+		var names []string
+		for _, o := range plan.KeptNodes {
+			names = append(names, o.Name)
+		}
+		for _, l := range plan.KeptLinks {
+			if len(l.Nodes) == 2 {
+				names = append(names, fmt.Sprintf("%s:%d/%d <---> %s:%d/%d",
+					plan.nodeName(l.Nodes[0].NodeID), l.Nodes[0].AdapterNumber, l.Nodes[0].PortNumber,
+					plan.nodeName(l.Nodes[1].NodeID), l.Nodes[1].AdapterNumber, l.Nodes[1].PortNumber))
+			}
+		}
+		fmt.Printf("\n🛡️  Kept by the prune policy: %s\n", strings.Join(names, ", "))
+	}
+	if len(plan.CreateLinks)+len(plan.DeleteLinks) > 0 {
+		fmt.Println("\n🔗 Links:")
+		for _, l := range plan.CreateLinks {
+			fmt.Printf("  ➕ create %s:%d/%d <---> %s:%d/%d\n",
+				l.Nodes[0].NodeName, l.Nodes[0].AdapterNumber, l.Nodes[0].PortNumber,
+				l.Nodes[1].NodeName, l.Nodes[1].AdapterNumber, l.Nodes[1].PortNumber)
+		}
+		for _, l := range plan.DeleteLinks {
+			if len(l.Nodes) != 2 {
+				fmt.Printf("  🗑️  delete link %s\n", l.ID)
+				continue
+			}
+			fmt.Printf("  🗑️  delete %s:%d/%d <---> %s:%d/%d (%s)\n",
+				plan.nodeName(l.Nodes[0].NodeID), l.Nodes[0].AdapterNumber, l.Nodes[0].PortNumber,
+				plan.nodeName(l.Nodes[1].NodeID), l.Nodes[1].AdapterNumber, l.Nodes[1].PortNumber, l.ID)
+		}
+	}
+	if len(plan.Imports)+len(plan.Removals) > 0 {
+		fmt.Println("\n🧱 Terraform state:")
+		for _, addr := range plan.Imports {
+			fmt.Printf("  📥 import %s\n", addr)
+		}
+		for _, addr := range plan.Removals {
+			fmt.Printf("  🗑️  state rm %s\n", addr)
+		}
+	}
+}
+
+func (p ReconcilePlan) nodeName(id string) string {
+	if n, ok := p.names[id]; ok {
+		return n
+	}
+	return id
+}
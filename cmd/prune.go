@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"path"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+// Prune policies (project.prune.policy): what the reconciler may delete.
+const (
+	pruneNever       = "never"        // delete nothing
+	pruneManagedOnly = "managed-only" // only nodes it manages and links between them
+	pruneAll         = "all"          // everything not in the topology
+)
+
+// pruner holds back the deletions project.prune does not allow.
+type pruner struct {
+	policy  string
+	protect []string
+	max     int
+
+	managed   map[string]bool // node IDs the topology manages
+	protected map[string]bool // node IDs matching a protect pattern
+}
+
+func newPruner(p topology.Prune) *pruner {
+	policy := p.Policy
+	if policy == "" {
+		policy = pruneManagedOnly
+	}
+	return &pruner{policy: policy, protect: p.Protect, max: p.MaxDeletions}
+}
+
+// isProtected reports whether a node name matches a protect pattern.
+func (p *pruner) isProtected(name string) bool {
+	for _, pat := range p.protect {
+		if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// observe records which observed nodes are managed and which protected, for
+// pruneLinks.
+func (p *pruner) observe(nodes []ObservedNode, state *reconcileState) {
+	p.managed = make(map[string]bool, len(state.Nodes))
+	for _, id := range state.Nodes {
+		p.managed[id] = true
+	}
+	p.protected = make(map[string]bool)
+	for _, o := range nodes {
+		if p.isProtected(o.Name) {
+			p.protected[o.ID] = true
+		}
+	}
+}
+
+// pruneNodes applies the policy to a node match. With "all", unmanaged
+// nodes are deleted too; with "never", nothing is. Protected nodes are never
+// deleted. Held-back deletions move to m.Kept.
+func (p *pruner) pruneNodes(m *nodeMatch) {
+	candidates := m.ToDelete
+	switch p.policy {
+	case pruneNever:
+		m.Kept, m.ToDelete = append(m.Kept, candidates...), nil
+		return
+	case pruneAll:
+		candidates = append(candidates, m.Unmanaged...)
+		m.Unmanaged = nil
+	}
+	m.ToDelete = nil
+	for _, o := range candidates {
+		if p.isProtected(o.Name) {
+			m.Kept = append(m.Kept, o)
+			continue
+		}
+		m.ToDelete = append(m.ToDelete, o)
+	}
+}
+
+// pruneDrift keeps protected nodes, and every node under "never", from
+// being recreated; their drift is only reported.
+func (p *pruner) pruneDrift(drifts []nodeDrift) {
+	for i, d := range drifts {
+		if d.Action == driftRecreate && (p.policy == pruneNever || p.isProtected(d.Name)) {
+			drifts[i].Action = driftWarn
+		}
+	}
+}
+
+// pruneLinks splits the links a pass wants to delete into those the policy
+// allows and those it keeps. "managed-only" deletes only links between two
+// managed nodes; no policy deletes a link of a protected node.
+func (p *pruner) pruneLinks(toDel []ObservedLink) (del, kept []ObservedLink) {
+	for _, l := range toDel {
+		allowed := p.policy != pruneNever
+		for _, ep := range l.Nodes {
+			if p.protected[ep.NodeID] || (p.policy == pruneManagedOnly && !p.managed[ep.NodeID]) {
+				allowed = false
+			}
+		}
+		if allowed {
+			del = append(del, l)
+		} else {
+			kept = append(kept, l)
+		}
+	}
+	return del, kept
+}
@@ -7,21 +7,119 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
-	"gopkg.in/yaml.v2"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+// ReconcileOptions tune the reconcile daemon. Zero durations and counts
+// fall back to project.reconcile in the topology, then to the defaults.
+type ReconcileOptions struct {
+	DryRun          bool          // only log the plan of each pass, change nothing
+	Interval        time.Duration // between periodic passes
+	Jitter          time.Duration // random extra delay per pass
+	Debounce        time.Duration // quiet time after the last file change
+	MaxBackoff      time.Duration // cap of the delay after failed passes
+	MaxFailures     int           // consecutive failed passes before stopping
+	NoHeal          bool          // do not reconfigure routers the daemon creates
+	MetricsAddr     string        // serve Prometheus metrics here; empty disables them
+	WebhookAddr     string        // accept Git webhooks here; empty disables them
+	WebhookInsecure bool          // accept unauthenticated webhooks when no secret is set
+}
+
+// Daemon defaults for settings neither flags nor the topology set.
+const (
+	defaultReconcileDebounce   = 2 * time.Second
+	defaultReconcileMaxBackoff = 10 * time.Minute
 )
 
-// startReconcileDaemon watches the YAML, then runs an initial + on-change + periodic reconcile pass.
-func StartReconcileDaemon(yamlPath, projectID string) {
+// withSettings fills the options left unset from the topology's
+// project.reconcile and then from the defaults.
+func (o ReconcileOptions) withSettings(s topology.ReconcileSettings) ReconcileOptions {
+	pick := func(flag, topo, def time.Duration) time.Duration {
+		if flag > 0 {
+			return flag
+		}
+		if topo > 0 {
+			return topo
+		}
+		return def
+	}
+	o.Interval = pick(o.Interval, s.Interval, reconcileInterval)
+	o.Jitter = pick(o.Jitter, s.Jitter, 0)
+	o.Debounce = pick(o.Debounce, s.Debounce, defaultReconcileDebounce)
+	o.MaxBackoff = pick(o.MaxBackoff, s.MaxBackoff, defaultReconcileMaxBackoff)
+	if o.MaxFailures == 0 {
+		o.MaxFailures = s.MaxFailures
+	}
+	return o
+}
+
+// args renders the options as __reconcile_daemon flags.
+func (o ReconcileOptions) args() []string {
+	var args []string
+	if o.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if o.NoHeal {
+		args = append(args, "--no-heal")
+	}
+	for _, d := range []struct {
+		flag string
+		v    time.Duration
+	}{{"--interval", o.Interval}, {"--jitter", o.Jitter}, {"--debounce", o.Debounce}, {"--max-backoff", o.MaxBackoff}} {
+		if d.v > 0 {
+			args = append(args, d.flag, d.v.String())
+		}
+	}
+	if o.MaxFailures > 0 {
+		args = append(args, "--max-failures", strconv.Itoa(o.MaxFailures))
+	}
+	if o.MetricsAddr != "" {
+		args = append(args, "--metrics-addr", o.MetricsAddr)
+	}
+	if o.WebhookAddr != "" {
+		args = append(args, "--webhook-addr", o.WebhookAddr)
+	}
+	if o.WebhookInsecure {
+		args = append(args, "--webhook-insecure")
+	}
+	return args
+}
+
+// nextDelay is the wait before the next periodic pass: the interval plus
+// jitter, doubled for every consecutive failure up to MaxBackoff.
+func (o ReconcileOptions) nextDelay(failures int) time.Duration {
+	d := o.Interval
+	for i := 0; i < failures && d < o.MaxBackoff; i++ {
+		d *= 2
+	}
+	if failures > 0 && d > o.MaxBackoff {
+		d = o.MaxBackoff
+	}
+	if o.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(o.Jitter)))
+	}
+	return d
+}
+
+// StartReconcileDaemon watches the YAML, then runs an initial, on-change
+// (debounced) and periodic reconcile pass. A topology that fails to load
+// or validate is rejected and the last known good one is reconciled
+// instead; failed passes back off exponentially.
+func StartReconcileDaemon(yamlPath, projectID string, opts ReconcileOptions) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "❌ watcher error: %v\n", err)
@@ -35,11 +133,103 @@ func StartReconcileDaemon(yamlPath, projectID string) {
 		return
 	}
 
+	var healer *configHealer
+	if !opts.DryRun && !opts.NoHeal {
+		healer = newConfigHealer()
+	}
+	if !opts.DryRun {
+		audit = &auditLog{}
+	}
+	if opts.MetricsAddr != "" {
+		metrics = newReconcileMetrics()
+		if err := metrics.serve(opts.MetricsAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return
+		}
+	}
+	control = newDaemonControl(yamlPath, projectID)
+	defer control.close()
+	// Listen before the first pass so the daemon can be inspected while the
+	// topology is rejected; an unreadable name defers it to the first good load.
+	if name, err := topology.ProjectName(yamlPath); err == nil {
+		control.listen(name)
+	}
+	if opts.WebhookAddr != "" {
+		if err := control.listenWebhook(opts.WebhookAddr, opts.WebhookInsecure); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			return
+		}
+	}
+	var lastGood *Topology
+	failures := 0
+	effective := opts.withSettings(topology.ReconcileSettings{})
+	// pass reloads the topology and reconciles it, tracking failures. It
+	// reports whether the daemon should stop.
+	pass := func(trigger string) bool {
+		if control.isPaused() {
+			fmt.Printf("⏸️  Reconciliation paused; skipping the %s pass\n", trigger)
+			return false
+		}
+		if trigger == triggerWebhook {
+			control.pullTopology()
+		}
+		topo, loadErr := loadReconcileTopology(yamlPath)
+		control.topologyRejected(loadErr)
+		if loadErr != nil {
+			fmt.Fprintf(os.Stderr, "❌ topology rejected: %v\n", loadErr)
+			if lastGood == nil {
+				fmt.Fprintln(os.Stderr, "⏸️  No valid topology loaded yet; waiting for the file to be fixed")
+				return false
+			}
+			fmt.Fprintln(os.Stderr, "↩️  Reconciling the last known good topology instead")
+		} else {
+			lastGood = &topo
+			effective = opts.withSettings(topo.Project.Reconcile)
+		}
+		control.listen(lastGood.Project.Name)
+		audit.beginPass(lastGood.Project.Name, trigger)
+		if loadErr != nil {
+			audit.record(auditEvent{Action: "topology.rejected", Detail: "reconciling the last known good topology"}, loadErr)
+		}
+		audit.record(auditEvent{Action: "pass.start", Detail: filepath.Base(yamlPath)}, nil)
+		start := time.Now()
+		control.passStarted(trigger)
+		err := runReconcile(*lastGood, projectID, effective, healer)
+		metrics.pass(trigger, start, err)
+		audit.record(auditEvent{Action: "pass.end"}, err)
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		control.passDone(err, failures)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ reconcile pass failed (%d in a row): %v\n", failures, err)
+			if effective.MaxFailures > 0 && failures >= effective.MaxFailures {
+				fmt.Fprintf(os.Stderr, "🛑 %d consecutive failed passes; stopping the reconcile daemon.\n", failures)
+				return true
+			}
+		}
+		return false
+	}
+	// wait is the delay before the next periodic pass.
+	wait := func() time.Duration {
+		delay := effective.nextDelay(failures)
+		if failures > 0 {
+			fmt.Printf("⏳ Backing off: next pass in %s\n", delay.Round(time.Second))
+		}
+		control.nextPass(time.Now().Add(delay))
+		return delay
+	}
+
 	// initial pass
-	runReconcile(yamlPath, projectID)
+	if pass(triggerInitial) {
+		return
+	}
 
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
+	timer := time.NewTimer(wait())
+	defer timer.Stop()
+	var debounce <-chan time.Time
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
@@ -48,13 +238,32 @@ func StartReconcileDaemon(yamlPath, projectID string) {
 		select {
 		case ev := <-watcher.Events:
 			if filepath.Clean(ev.Name) == filepath.Clean(yamlPath) &&
-				(ev.Op&fsnotify.Write|fsnotify.Create|fsnotify.Rename != 0) {
-				fmt.Println("📄 topology.yaml changed; reconciling…")
-				runReconcile(yamlPath, projectID)
+				ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				// Editors save in bursts; wait until the file is quiet.
+				debounce = time.After(effective.Debounce)
+			}
+		case <-debounce:
+			debounce = nil
+			fmt.Printf("📄 %s changed; reconciling…\n", filepath.Base(yamlPath))
+			if pass(triggerFile) {
+				return
 			}
-		case <-ticker.C:
-			fmt.Println("⏱️  Periodic reconcile…")
-			runReconcile(yamlPath, projectID)
+		case <-timer.C:
+			if failures > 0 {
+				fmt.Printf("⏱️  Retrying reconcile (failure %d)…\n", failures)
+			} else {
+				fmt.Println("⏱️  Periodic reconcile…")
+			}
+			if pass(triggerTimer) {
+				return
+			}
+			timer.Reset(wait())
+		case trigger := <-control.pending():
+			fmt.Printf("🎛️  Pass requested (%s); reconciling…\n", trigger)
+			if pass(trigger) {
+				return
+			}
+			timer.Reset(wait())
 		case <-stop:
 			fmt.Println("\n🛑 Reconcile daemon stopped.")
 			return
@@ -62,27 +271,66 @@ func StartReconcileDaemon(yamlPath, projectID string) {
 	}
 }
 
-// runReconcile watches your YAML, reconciles GNS3, and then
-// delta-syncs exactly the nodes you added or deleted.
-func runReconcile(yamlPath, projectID string) {
-	// 1) Load topology
+// loadReconcileTopology loads and validates the topology for a pass, so a
+// half-saved or invalid file is never reconciled.
+func loadReconcileTopology(yamlPath string) (Topology, error) {
 	topo, err := loadTopology(yamlPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ failed to load topology: %v\n", err)
-		return
+		return topo, err
 	}
+	if err := validateTopology(&topo); err != nil {
+		return topo, err
+	}
+	return topo, nil
+}
+
+// runReconcile reconciles GNS3 with the topology, delta-syncs exactly the
+// nodes and links added or deleted, and hands the routers it created to
+// heal, if any, to be configured. It returns an error when
+// the pass could not run, e.g. GNS3 is unreachable; failures of single
+// nodes or links are logged and retried on the next pass.
+func runReconcile(topo Topology, projectID string, opts ReconcileOptions, heal *configHealer) error {
 	gns3Server = topo.Project.GNS3Server
-	if gns3Server == "" {
-		gns3Server = "http://localhost:3080" // or your actual default
+	if opts.DryRun {
+		plan, err := buildReconcilePlan(topo, projectID)
+		if err != nil {
+			return fmt.Errorf("plan: %w", err)
+		}
+		printReconcilePlan(topo.Project.Name, projectID, plan)
+		fmt.Println("🧪 Dry run: no changes made")
+		return nil
 	}
+	prune := newPruner(topo.Project.Prune)
+	if prune.max > 0 {
+		plan, err := buildReconcilePlan(topo, projectID)
+		if err != nil {
+			return fmt.Errorf("plan: %w", err)
+		}
+		if n := plan.deletions(); n > prune.max {
+			fmt.Fprintf(os.Stderr, "\n🚨🚨🚨 RECONCILE ABORTED: this pass would delete %d nodes/links, more than prune.max_deletions (%d).\n", n, prune.max)
+			fmt.Fprintln(os.Stderr, "🚨 Nothing was changed. Check the topology file, or raise the limit if the deletions are intended:")
+			printReconcilePlan(topo.Project.Name, projectID, plan)
+			audit.record(auditEvent{Action: "pass.abort"},
+				fmt.Errorf("would delete %d nodes/links, more than prune.max_deletions (%d)", n, prune.max))
+			return nil
+		}
+	}
+
 	// 2) Build desired nodes and links
 	desiredNodes, desiredLinksByName := BuildDesired(topo)
 
+	state, err := loadReconcileState(topo.Project.Name, projectID)
+	if err != nil {
+		return err
+	}
+
 	// 3) Reconcile nodes (create/delete)
-	_, addedNodes, deletedNodes, err := reconcileNodes(desiredNodes, projectID)
+	_, addedNodes, deletedNodes, err := reconcileNodes(desiredNodes, projectID, state, topo.Project.Drift, prune)
+	if saveErr := state.save(topo.Project.Name); saveErr != nil {
+		fmt.Fprintf(os.Stderr, "⚠️ could not save reconcile state: %v\n", saveErr)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ node reconcile: %v\n", err)
-		return
+		return fmt.Errorf("node reconcile: %w", err)
 	}
 
 	// 4) Fetch observed nodes and map names to IDs
@@ -95,47 +343,32 @@ func runReconcile(yamlPath, projectID string) {
 		}
 	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "❌ fetchNodes: %v\n", err)
-		return
+		metrics.apiError("GET /nodes")
+		return fmt.Errorf("fetchNodes: %w", err)
 	}
-	nameToID := buildNameToID(obsNodes)
+	nameToID := state.Nodes
 
 	// 5) Build resolved desired link payloads
 	var desiredLinks []LinkCreatePayload
-	linkNameMap := make(map[string]string) // YAML name → Terraform resource name
 	for _, ln := range desiredLinksByName {
-
-		if len(ln.Nodes) != 2 {
-			continue
-		}
-		var rp LinkCreatePayload
-		ok := true
-		var from, to string
-		for i, ep := range ln.Nodes {
-			id, found := nameToID[ep.NodeName]
-			if !found {
-				ok = false
-				break
-			}
-			rp.Nodes = append(rp.Nodes, linkEndpoint{
-				NodeID:        id,
-				AdapterNumber: ep.AdapterNumber,
-				PortNumber:    ep.PortNumber,
-			})
-			if i == 0 {
-				from = ep.NodeName
-			} else {
-				to = ep.NodeName
-			}
-		}
-		if ok {
+		if rp, ok := resolveLink(ln, nameToID); ok {
 			desiredLinks = append(desiredLinks, rp)
-			linkNameMap[fmt.Sprintf("%s_to_%s", from, to)] = fmt.Sprintf("%s_to_%s", from, to)
 		}
 	}
 
+	// idToName names managed nodes, and nodes this pass deleted, by their
+	// topology name
+	idToName := make(map[string]string, len(nameToID))
+	for name, id := range nameToID {
+		idToName[id] = name
+	}
+	for _, od := range deletedNodes {
+		idToName[od.ID] = od.Name
+	}
+
 	// 6) Reconcile links
-	addedLinks, deletedLinks := reconcileLinksWithTracking(desiredLinks, projectID)
+	prune.observe(obsNodes, state)
+	addedLinks, deletedLinks := reconcileLinksWithTracking(desiredLinks, projectID, prune, idToName)
 
 	// 7) Perform delta sync for both nodes and links
 	if len(addedNodes) > 0 || len(deletedNodes) > 0 || len(addedLinks) > 0 || len(deletedLinks) > 0 {
@@ -164,30 +397,20 @@ func runReconcile(yamlPath, projectID string) {
 		// Links: additions
 		for _, l := range addedLinks {
 			if len(l.Nodes) == 2 {
-				fromID := l.Nodes[0].NodeID
-				toID := l.Nodes[1].NodeID
-				fromName := findNodeNameByID(obsNodes, fromID)
-				toName := findNodeNameByID(obsNodes, toID)
-				tfName := fmt.Sprintf("%s_to_%s", fromName, toName)
 				toAdd = append(toAdd, TerraformResource{
 					Type: "gns3_link",
-					Name: tfName,
+					Name: observedLinkResourceName(l, idToName),
 					ID:   l.ID,
 				})
 			}
 		}
 
-		// Links: deletions
+		// Links: deletions (Terraform only knows links between managed nodes)
 		for _, l := range deletedLinks {
-			if len(l.Nodes) == 2 {
-				fromID := l.Nodes[0].NodeID
-				toID := l.Nodes[1].NodeID
-				fromName := findNodeNameByID(obsNodes, fromID)
-				toName := findNodeNameByID(obsNodes, toID)
-				tfName := fmt.Sprintf("%s_to_%s", fromName, toName)
+			if len(l.Nodes) == 2 && prune.managed[l.Nodes[0].NodeID] && prune.managed[l.Nodes[1].NodeID] {
 				toDel = append(toDel, TerraformResource{
 					Type: "gns3_link",
-					Name: tfName,
+					Name: observedLinkResourceName(l, idToName),
 					ID:   l.ID,
 				})
 			}
@@ -198,60 +421,119 @@ func runReconcile(yamlPath, projectID string) {
 		}
 	}
 
+	// 8) Report what the pass left in GNS3 to the control API
+	if control != nil {
+		if links, err := fetchLinksFromGNS3(projectID); err == nil {
+			control.counts(
+				itemCounts{Nodes: len(desiredNodes), Links: len(desiredLinksByName)},
+				itemCounts{Nodes: len(obsNodes), Links: len(links)},
+			)
+		}
+	}
+
+	// 9) Reconfigure the routers that came back blank
+	if heal != nil && len(addedNodes) > 0 {
+		var names []string
+		for _, nd := range addedNodes {
+			names = append(names, nd.Name)
+		}
+		heal.heal(topo, names)
+	}
+
 	fmt.Println("✅ Reconcile pass complete")
+	return nil
 }
 
-// findNodeNameByID returns the node name for a given node ID.
-func findNodeNameByID(nodes []ObservedNode, id string) string {
-	for _, n := range nodes {
-		if n.ID == id {
-			return n.Name
+// resolveLink turns a link between node names into one between node IDs.
+// It fails when an endpoint has no node yet.
+func resolveLink(ln LinkCreatePayload, nameToID map[string]string) (LinkCreatePayload, bool) {
+	if len(ln.Nodes) != 2 {
+		return LinkCreatePayload{}, false
+	}
+	var rp LinkCreatePayload
+	for _, ep := range ln.Nodes {
+		id, ok := nameToID[ep.NodeName]
+		if !ok {
+			return LinkCreatePayload{}, false
 		}
+		rp.Nodes = append(rp.Nodes, linkEndpoint{
+			NodeID:        id,
+			AdapterNumber: ep.AdapterNumber,
+			PortNumber:    ep.PortNumber,
+		})
 	}
-	return id // fallback to ID if name not found
+	return rp, true
+}
+
+// observedLinkResourceName is the Terraform name of a GNS3 link, with its
+// nodes named by idToName (node ID → topology name).
+func observedLinkResourceName(l ObservedLink, idToName map[string]string) string {
+	var ends [2]topology.Endpoint
+	for i, ep := range l.Nodes[:2] {
+		name, ok := idToName[ep.NodeID]
+		if !ok {
+			name = ep.NodeID
+		}
+		ends[i] = topology.Endpoint{Name: name, Adapter: ep.AdapterNumber, Port: ep.PortNumber}
+	}
+	return topology.LinkResourceName(ends[0], ends[1])
 }
 
 // reconcileLinksWithTracking reconciles links and returns added and deleted links with IDs.
-func reconcileLinksWithTracking(desired []LinkCreatePayload, projectID string) (added []ObservedLink, deleted []ObservedLink) {
+// Stale links are deleted first, so a link moved to another port can take
+// over a port its old cable used. names (node ID → name) labels links in
+// the audit log.
+func reconcileLinksWithTracking(desired []LinkCreatePayload, projectID string, prune *pruner, names map[string]string) (added []ObservedLink, deleted []ObservedLink) {
 	observed, err := fetchLinksFromGNS3(projectID)
 	if err != nil {
+		metrics.apiError("GET /links")
 		fmt.Fprintf(os.Stderr, "❌ fetchLinksFromGNS3: %v\n", err)
 		return
 	}
 
 	toAdd, toDel := diffLinks(desired, observed)
+	toDel, kept := prune.pruneLinks(toDel)
+	if len(kept) > 0 {
+		fmt.Printf("🛡️  Keeping %d link(s) not in the topology (prune policy %s)\n", len(kept), prune.policy)
+	}
+
+	for _, ol := range toDel {
+		fmt.Printf("🗑️  Deleting link %s…\n", ol.ID)
+		err := deleteLink(ol.ID, projectID)
+		audit.record(auditEvent{Action: "link.delete", Link: linkLabel(ol, names), ID: ol.ID}, err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "   ❌ deleteLink: %v\n", err)
+		} else {
+			deleted = append(deleted, ol)
+		}
+	}
 
 	for _, lp := range toAdd {
 		fmt.Printf("➕ Creating link %+v…\n", lp.Nodes)
-		if err := createLink(lp, projectID); err != nil {
+		err := createLink(lp, projectID)
+		if audit != nil {
+			var ol ObservedLink
+			for _, ep := range lp.Nodes {
+				ol.Nodes = append(ol.Nodes, ObservedLinkEndpoint{NodeID: ep.NodeID, AdapterNumber: ep.AdapterNumber, PortNumber: ep.PortNumber})
+			}
+			audit.record(auditEvent{Action: "link.create", Link: linkLabel(ol, names)}, err)
+		}
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "   ❌ createLink: %v\n", err)
 		} else {
 			// Re-fetch to obtain only the newly added link
 			newLinks, err := fetchLinksFromGNS3(projectID)
 			if err == nil {
 				for _, nl := range newLinks {
-					if len(nl.Nodes) == 2 {
-						a, b := nl.Nodes[0].NodeID, nl.Nodes[1].NodeID
-						ua, ub := lp.Nodes[0].NodeID, lp.Nodes[1].NodeID
-						if (a == ua && b == ub) || (a == ub && b == ua) {
-							added = append(added, nl)
-							break
-						}
+					if len(nl.Nodes) == 2 && nl.key() == lp.key() {
+						added = append(added, nl)
+						break
 					}
 				}
 			}
 		}
 	}
 
-	for _, ol := range toDel {
-		fmt.Printf("🗑️  Deleting link %s…\n", ol.ID)
-		if err := deleteLink(ol.ID, projectID); err != nil {
-			fmt.Fprintf(os.Stderr, "   ❌ deleteLink: %v\n", err)
-		} else {
-			deleted = append(deleted, ol)
-		}
-	}
-
 	return
 }
 
@@ -268,7 +550,9 @@ func syncTerraformDelta(
 		fmt.Printf("🗑️  Removing state for deleted %s\n", addr)
 		rmCmd := exec.Command("terraform", "state", "rm", addr)
 		rmCmd.Dir = tfDir
-		if out, err := rmCmd.CombinedOutput(); err != nil {
+		out, err := rmCmd.CombinedOutput()
+		audit.recordCommand(auditEvent{Action: "terraform.state_rm", Node: res.Name}, rmCmd, out, err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr,
 				"⚠️ state rm %s failed: %v\n%s\n",
 				addr, err, string(out),
@@ -292,7 +576,9 @@ func syncTerraformDelta(
 				fmt.Printf("🗑️  Removing stale state for %s\n", line)
 				rm := exec.Command("terraform", "state", "rm", line)
 				rm.Dir = tfDir
-				if ro, err := rm.CombinedOutput(); err != nil {
+				ro, err := rm.CombinedOutput()
+				audit.recordCommand(auditEvent{Action: "terraform.state_rm"}, rm, ro, err)
+				if err != nil {
 					fmt.Fprintf(os.Stderr, "⚠️ stale rm %s failed: %v\n%s\n", line, err, string(ro))
 				}
 			}
@@ -306,7 +592,7 @@ func syncTerraformDelta(
 		// Determine import ID
 		var importID string
 		switch res.Type {
-		case "gns3_qemu_node", "gns3_switch", "gns3_cloud", "gns3_template":
+		case "gns3_qemu_node", "gns3_docker", "gns3_iou", "gns3_dynamips", "gns3_switch", "gns3_cloud", "gns3_template":
 			importID = fmt.Sprintf("%s/%s", projectID, res.ID)
 		case "gns3_link":
 			if res.ID == "" {
@@ -328,6 +614,7 @@ func syncTerraformDelta(
 		impCmd := exec.Command("terraform", "import", fullAddr, importID)
 		impCmd.Dir = tfDir
 		out, err := impCmd.CombinedOutput()
+		audit.recordCommand(auditEvent{Action: "terraform.import", Node: res.Name}, impCmd, out, err)
 		if err != nil {
 			fmt.Fprintf(os.Stderr,
 				"⚠️ terraform import %s failed: %v\n%s\n",
@@ -341,47 +628,134 @@ func syncTerraformDelta(
 	return nil
 }
 
-func reconcileNodes(desired []NodeCreatePayload, projectID string) (changed bool, added []NodeCreatePayload, deleted []ObservedNode, err error) {
+func reconcileNodes(desired []NodeCreatePayload, projectID string, state *reconcileState, driftPolicy map[string]string, prune *pruner) (changed bool, added []NodeCreatePayload, deleted []ObservedNode, err error) {
 	// Fetch current GNS3 nodes
 	observed, err := fetchNodesFromGNS3(projectID)
 	if err != nil {
+		metrics.apiError("GET /nodes")
 		return false, nil, nil, err
 	}
 
-	// Diff to find what to add/delete
-	toAdd, toDel := diffRouters(desired, observed)
+	// Match by recorded node ID, then exact name
+	m := matchNodes(desired, observed, state)
+	prune.pruneNodes(&m)
+	for _, name := range m.Adopted {
+		fmt.Printf("🔗 Managing existing node %s\n", name)
+		audit.record(auditEvent{Action: "node.adopt", Node: name, ID: state.Nodes[name]}, nil)
+	}
+	if len(m.Unmanaged) > 0 {
+		var names []string
+		for _, o := range m.Unmanaged {
+			names = append(names, o.Name)
+		}
+		fmt.Printf("👤 Leaving %d unmanaged node(s) alone: %s\n", len(names), strings.Join(names, ", "))
+	}
+	if len(m.Kept) > 0 {
+		var names []string
+		for _, o := range m.Kept {
+			names = append(names, o.Name)
+		}
+		fmt.Printf("🛡️  Keeping %d node(s) not in the topology (prune policy %s): %s\n", len(names), prune.policy, strings.Join(names, ", "))
+	}
+	for name, o := range m.Matched {
+		if o.Name != name {
+			fmt.Printf("🔤 Node %s was renamed to %q in GNS3; restoring its name…\n", name, o.Name)
+			err := renameNode(o.ID, projectID, name)
+			audit.record(auditEvent{Action: "node.rename", Node: name, ID: o.ID, Detail: fmt.Sprintf("was %q", o.Name)}, err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "   ❌ renameNode: %v\n", err)
+			}
+		}
+	}
+
+	// Fix property drift per policy
+	restarted := make(map[string]bool)
+	drifts := detectDrift(desired, m, driftPolicy)
+	prune.pruneDrift(drifts)
+	for _, d := range drifts {
+		metrics.driftDetected(d.Action)
+		switch d.Action {
+		case driftWarn:
+			fmt.Printf("⚠️  Node %s drifted (%s); left as is by drift policy\n", d.Name, d.describeChanges())
+			audit.record(auditEvent{Action: "node.drift", Node: d.Name, ID: d.Node.ID, Detail: d.describeChanges()}, nil)
+		case driftUpdate:
+			fmt.Printf("🧬 Node %s drifted (%s); updating…\n", d.Name, d.describeChanges())
+			err := fixDrift(d, projectID)
+			audit.record(auditEvent{Action: "node.update", Node: d.Name, ID: d.Node.ID, Detail: d.describeChanges()}, err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "   ❌ update node: %v\n", err)
+			}
+			restarted[d.Name] = d.Stop
+		case driftRecreate:
+			fmt.Printf("♻️  Node %s drifted (%s); recreating…\n", d.Name, d.describeChanges())
+			err := deleteNode(d.Node.ID, projectID)
+			audit.record(auditEvent{Action: "node.delete", Node: d.Name, ID: d.Node.ID, Detail: "recreate: " + d.describeChanges()}, err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "   ❌ deleteNode: %v\n", err)
+				continue
+			}
+			delete(state.Nodes, d.Name)
+			old := d.Node
+			old.Name = d.Name // Terraform knows it by its topology name
+			deleted = append(deleted, old)
+			changed, restarted[d.Name] = true, true
+			id, err := createNode(d.Desired, projectID)
+			audit.record(auditEvent{Action: "node.create", Node: d.Name, ID: id, Detail: "recreate"}, err)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "   ❌ createNode: %v\n", err)
+				continue
+			}
+			state.Nodes[d.Name] = id
+			added = append(added, d.Desired)
+		}
+	}
 
 	// Create missing nodes
-	for _, nd := range toAdd {
+	for _, nd := range m.ToAdd {
 		fmt.Printf("➕ Creating node %s…\n", nd.Name)
-		if err := createNode(nd, projectID); err != nil {
+		id, err := createNode(nd, projectID)
+		audit.record(auditEvent{Action: "node.create", Node: nd.Name, ID: id}, err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "   ❌ createNode: %v\n", err)
 		} else {
+			state.Nodes[nd.Name] = id
 			changed = true
 			added = append(added, nd)
 		}
 	}
 
-	// Delete extra nodes
-	for _, o := range toDel {
-		fmt.Printf("🗑️  Deleting node %s…\n", o.Name)
-		if err := deleteNode(o.ID, projectID); err != nil {
+	// Delete nodes that left the topology, as the prune policy allows
+	for _, o := range m.ToDelete {
+		name, managed := o.Name, false
+		for n, id := range state.Nodes {
+			if id == o.ID {
+				name, managed = n, true
+			}
+		}
+		fmt.Printf("🗑️  Deleting node %s…\n", name)
+		err := deleteNode(o.ID, projectID)
+		detail := "not in the topology"
+		if !managed {
+			detail += ", unmanaged (prune policy all)"
+		}
+		audit.record(auditEvent{Action: "node.delete", Node: name, ID: o.ID, Detail: detail}, err)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "   ❌ deleteNode: %v\n", err)
-		} else {
+		} else if managed {
+			delete(state.Nodes, name)
+			o.Name = name // Terraform knows it by its topology name
 			changed = true
 			deleted = append(deleted, o)
 		}
 	}
 
 	// Ensure desired nodes are running
-	desiredSet := make(map[string]struct{}, len(desired))
-	for _, d := range desired {
-		desiredSet[d.Name] = struct{}{}
-	}
-	for _, o := range observed {
-		if _, want := desiredSet[o.Name]; want && o.Status != "started" {
-			fmt.Printf("🔄 Starting node %s (was %s)…\n", o.Name, o.Status)
-			if err := startNode(o.ID, projectID); err != nil {
+	for name, o := range m.Matched {
+		if o.Status != "started" && !restarted[name] {
+			fmt.Printf("🔄 Starting node %s (was %s)…\n", name, o.Status)
+			err := startNode(o.ID, projectID)
+			audit.record(auditEvent{Action: "node.start", Node: name, ID: o.ID, Detail: "was " + o.Status}, err)
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "   ❌ startNode: %v\n", err)
 			}
 		}
@@ -390,37 +764,6 @@ func reconcileNodes(desired []NodeCreatePayload, projectID string) (changed bool
 	return changed, added, deleted, nil
 }
 
-func reconcileLinks(desired []LinkCreatePayload, projectID string) (changed bool, added []LinkCreatePayload, deleted []ObservedLink, err error) {
-	observed, err := fetchLinksFromGNS3(projectID)
-	if err != nil {
-		return false, nil, nil, err
-	}
-
-	toAdd, toDel := diffLinks(desired, observed)
-
-	for _, lp := range toAdd {
-		fmt.Printf("➕ Creating link %v…\n", lp.Nodes)
-		if err := createLink(lp, projectID); err != nil {
-			fmt.Fprintf(os.Stderr, "   ❌ createLink: %v\n", err)
-		} else {
-			changed = true
-			added = append(added, lp)
-		}
-	}
-
-	for _, ol := range toDel {
-		fmt.Printf("🗑️  Deleting link %s…\n", ol.ID)
-		if err := deleteLink(ol.ID, projectID); err != nil {
-			fmt.Fprintf(os.Stderr, "   ❌ deleteLink: %v\n", err)
-		} else {
-			changed = true
-			deleted = append(deleted, ol)
-		}
-	}
-
-	return changed, added, deleted, nil
-}
-
 // listGlobalTemplates fetches all available global templates from GNS3.
 func listGlobalTemplates() ([]Template, error) {
 	url := fmt.Sprintf("%s/v2/templates", strings.TrimRight(gns3Server, "/"))
@@ -440,104 +783,59 @@ func listGlobalTemplates() ([]Template, error) {
 	return templates, nil
 }
 
-// loadTopology reads & unmarshals the YAML file into Topology.
-func loadTopology(path string) (Topology, error) {
-	var t Topology
-	data, err := ioutil.ReadFile(path)
-	if err != nil {
-		return t, err
+// linkKey identifies a link by its two ends, in either order. The ends are
+// node IDs or names, or ports as rendered by portKey.
+type linkKey struct{ a, b string }
+
+func newLinkKey(u, v string) linkKey {
+	if u > v {
+		u, v = v, u
 	}
-	return t, yaml.Unmarshal(data, &t)
+	return linkKey{u, v}
 }
 
-// buildNameToID creates a map from both full names and their “prefix” (before last dash) → node ID.
-func buildNameToID(nodes []ObservedNode) map[string]string {
-	m := make(map[string]string, len(nodes)*2)
-	for _, n := range nodes {
-		m[n.Name] = n.ID
-		if idx := strings.LastIndex(n.Name, "-"); idx != -1 {
-			prefix := n.Name[:idx]
-			if _, ok := m[prefix]; !ok {
-				m[prefix] = n.ID
-			}
-		}
-	}
-	return m
+// portKey renders one end of a link as node/adapter/port.
+func portKey(node string, adapter, port int) string {
+	return fmt.Sprintf("%s/%d/%d", node, adapter, port)
 }
 
-func diffRouters(
-	desired []NodeCreatePayload,
-	observed []ObservedNode,
-) (toAdd []NodeCreatePayload, toDel []ObservedNode) {
-	used := make(map[string]bool)
-	for _, d := range desired {
-		found := false
-		for _, o := range observed {
-			if strings.HasPrefix(o.Name, d.Name) && !used[o.Name] {
-				used[o.Name] = true
-				found = true
-				break
-			}
-		}
-		if !found {
-			toAdd = append(toAdd, d)
-		}
-	}
-	for _, o := range observed {
-		matched := false
-		for _, d := range desired {
-			if strings.HasPrefix(o.Name, d.Name) {
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			toDel = append(toDel, o)
-		}
-	}
-	return
+// key identifies the link by both of its ports.
+func (lp LinkCreatePayload) key() linkKey {
+	a, b := lp.Nodes[0], lp.Nodes[1]
+	return newLinkKey(portKey(a.NodeID, a.AdapterNumber, a.PortNumber), portKey(b.NodeID, b.AdapterNumber, b.PortNumber))
 }
 
+// key identifies the link by both of its ports.
+func (l ObservedLink) key() linkKey {
+	a, b := l.Nodes[0], l.Nodes[1]
+	return newLinkKey(portKey(a.NodeID, a.AdapterNumber, a.PortNumber), portKey(b.NodeID, b.AdapterNumber, b.PortNumber))
+}
+
+// diffLinks matches links by the ports at both ends, so a cable moved to
+// another port is deleted and recreated, and parallel links between the
+// same two nodes are each kept.
 func diffLinks(
 	desired []LinkCreatePayload,
 	observed []ObservedLink,
 ) (toAdd []LinkCreatePayload, toDel []ObservedLink) {
-	type key struct{ a, b string }
-	obsSet := make(map[key]ObservedLink, len(observed))
+	obsSet := make(map[linkKey]bool, len(observed))
 	for _, o := range observed {
 		if len(o.Nodes) == 2 {
-			u, v := o.Nodes[0].NodeID, o.Nodes[1].NodeID
-			if u > v {
-				u, v = v, u
-			}
-			obsSet[key{u, v}] = o
+			obsSet[o.key()] = true
 		}
 	}
+	desSet := make(map[linkKey]bool, len(desired))
 	for _, d := range desired {
 		if len(d.Nodes) != 2 {
 			continue
 		}
-		u, v := d.Nodes[0].NodeID, d.Nodes[1].NodeID
-		if u > v {
-			u, v = v, u
-		}
-		k := key{u, v}
-		if _, ok := obsSet[k]; !ok {
+		desSet[d.key()] = true
+		if !obsSet[d.key()] {
 			toAdd = append(toAdd, d)
 		}
 	}
-	desSet := make(map[key]struct{}, len(desired))
-	for _, d := range desired {
-		if len(d.Nodes) == 2 {
-			u, v := d.Nodes[0].NodeID, d.Nodes[1].NodeID
-			if u > v {
-				u, v = v, u
-			}
-			desSet[key{u, v}] = struct{}{}
-		}
-	}
-	for k, o := range obsSet {
-		if _, keep := desSet[k]; !keep {
+	for _, o := range observed {
+		if len(o.Nodes) == 2 && !desSet[o.key()] {
 			toDel = append(toDel, o)
 		}
 	}
@@ -558,9 +856,20 @@ func fetchNodesFromGNS3(projectID string) ([]ObservedNode, error) {
 		return nil, fmt.Errorf("API %d: %s", resp.StatusCode, data)
 	}
 	var out []ObservedNode
-	if err := json.Unmarshal(data, &out); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep property numbers comparable with the topology's ints
+	if err := dec.Decode(&out); err != nil {
 		return nil, err
 	}
+	for i := range out {
+		if out[i].Properties == nil {
+			out[i].Properties = map[string]interface{}{}
+		}
+		// console_type is a node attribute, compared like a property
+		if _, ok := out[i].Properties["console_type"]; !ok && out[i].ConsoleType != "" {
+			out[i].Properties["console_type"] = out[i].ConsoleType
+		}
+	}
 	return out, nil
 }
 
@@ -591,6 +900,7 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 			Name:         r.Name,          // node name, e.g. "R1"
 			TemplateName: r.TemplateName,  // template name, e.g. "arista-eos"
 			ResourceType: "gns3_template", // will trigger template node creation
+			Position:     r.Position,
 		})
 		routerNames[r.Name] = true
 	}
@@ -602,17 +912,8 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 				Name:         r.Name,
 				TemplateName: "qemu",
 				ResourceType: "gns3_qemu_node",
-				Properties: map[string]interface{}{
-					"name":           r.Name,
-					"adapter_type":   "e1000",
-					"hda_disk_image": r.Image,
-					"mac_address":    r.MacAddress,
-					"adapters":       10,
-					"ram":            2048,
-					"cpus":           2,
-					"platform":       "x86_64",
-					"console_type":   "telnet",
-				},
+				Properties:   qemuProperties(r),
+				Position:     r.Position,
 			})
 		}
 	}
@@ -623,9 +924,60 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 			Name:         srv.Name,
 			TemplateName: srv.Name,
 			ResourceType: "gns3_template",
+			Position:     srv.Position,
 		})
 	}
 
+	// Docker hosts
+	for _, d := range t.Docker {
+		nodes = append(nodes, NodeCreatePayload{
+			Name:         d.Name,
+			TemplateName: "docker",
+			ResourceType: "gns3_docker",
+			Properties:   dockerProperties(d),
+			Position:     d.Position,
+		})
+	}
+
+	// VPCS, NAT and hubs come from GNS3 templates
+	for _, n := range t.TemplateNodes() {
+		nodes = append(nodes, NodeCreatePayload{
+			Name:         n.Name,
+			TemplateName: n.TemplateName,
+			ResourceType: "gns3_template",
+			Position:     n.Position,
+		})
+	}
+
+	// IOU and Dynamips are built from their image, or from their template
+	// with the properties they set applied on top
+	for _, n := range t.IOU {
+		nd := NodeCreatePayload{
+			Name:         n.Name,
+			TemplateName: "iou",
+			ResourceType: "gns3_iou",
+			Properties:   iouProperties(n),
+			Position:     n.Position,
+		}
+		if n.Image == "" {
+			nd.TemplateName, nd.ResourceType = n.TemplateName, "gns3_template"
+		}
+		nodes = append(nodes, nd)
+	}
+	for _, n := range t.Dynamips {
+		nd := NodeCreatePayload{
+			Name:         n.Name,
+			TemplateName: "dynamips",
+			ResourceType: "gns3_dynamips",
+			Properties:   dynamipsProperties(n),
+			Position:     n.Position,
+		}
+		if n.Image == "" {
+			nd.TemplateName, nd.ResourceType = n.TemplateName, "gns3_template"
+		}
+		nodes = append(nodes, nd)
+	}
+
 	// Switches
 	for _, s := range t.Switches {
 		nodes = append(nodes, NodeCreatePayload{
@@ -633,6 +985,7 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 			TemplateName: "ethernet_switch",
 			TemplateID:   "ethernet_switch",
 			ResourceType: "gns3_switch",
+			Position:     s.Position,
 		})
 	}
 
@@ -643,6 +996,7 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 			TemplateName: "cloud",
 			TemplateID:   "cloud",
 			ResourceType: "gns3_cloud",
+			Position:     c.Position,
 		})
 	}
 
@@ -671,13 +1025,111 @@ func BuildDesired(t Topology) (nodes []NodeCreatePayload, links []LinkCreatePayl
 	return
 }
 
+// qemuProperties builds the GNS3 QEMU node properties of a router from its
+// resolved compute settings.
+func qemuProperties(r NetworkDevice) map[string]interface{} {
+	props := map[string]interface{}{
+		"adapter_type":   r.AdapterType,
+		"hda_disk_image": r.Image,
+		"mac_address":    r.MacAddress,
+		"adapters":       r.Adapters,
+		"ram":            r.RAM,
+		"cpus":           r.CPUs,
+		"platform":       r.Platform,
+		"console_type":   r.ConsoleType,
+	}
+	if r.Options != "" {
+		props["options"] = r.Options
+	}
+	return props
+}
+
+// dockerProperties builds the GNS3 Docker node properties of a host.
+func dockerProperties(d DockerNode) map[string]interface{} {
+	props := map[string]interface{}{
+		"image":        d.Image,
+		"adapters":     d.Adapters,
+		"console_type": d.ConsoleType,
+	}
+	if d.StartCommand != "" {
+		props["start_command"] = d.StartCommand
+	}
+	if len(d.Environment) > 0 {
+		keys := make([]string, 0, len(d.Environment))
+		for k := range d.Environment {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var env []string
+		for _, k := range keys {
+			env = append(env, k+"="+d.Environment[k])
+		}
+		props["environment"] = strings.Join(env, "\n")
+	}
+	return props
+}
+
+// iouProperties builds the GNS3 IOU node properties of a node. Unset
+// fields are left out so a template keeps its own values.
+func iouProperties(n IOUNode) map[string]interface{} {
+	props := map[string]interface{}{}
+	for k, v := range map[string]interface{}{
+		"path":              n.Image,
+		"ram":               n.RAM,
+		"nvram":             n.NVRAM,
+		"ethernet_adapters": n.EthernetAdapters,
+		"serial_adapters":   n.SerialAdapters,
+	} {
+		if !isZeroProperty(v) {
+			props[k] = v
+		}
+	}
+	return props
+}
+
+// dynamipsProperties builds the GNS3 Dynamips node properties of a router,
+// one slotN and wicN property per listed module.
+func dynamipsProperties(n DynamipsNode) map[string]interface{} {
+	props := map[string]interface{}{}
+	for k, v := range map[string]interface{}{
+		"platform": n.Platform,
+		"image":    n.Image,
+		"ram":      n.RAM,
+		"nvram":    n.NVRAM,
+		"idlepc":   n.IdlePC,
+	} {
+		if !isZeroProperty(v) {
+			props[k] = v
+		}
+	}
+	for i, m := range n.Slots {
+		if m != "" {
+			props[fmt.Sprintf("slot%d", i)] = m
+		}
+	}
+	for i, m := range n.WICs {
+		if m != "" {
+			props[fmt.Sprintf("wic%d", i)] = m
+		}
+	}
+	return props
+}
+
 func deleteNode(nodeID, projectID string) error {
 	req, _ := http.NewRequest("DELETE",
 		fmt.Sprintf("%s/v2/projects/%s/nodes/%s", strings.TrimRight(gns3Server, "/"), projectID, nodeID),
 		nil,
 	)
-	_, err := http.DefaultClient.Do(req)
-	return err
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete node API %d: %s", resp.StatusCode, data)
+	}
+	return nil
 }
 
 func startNode(nodeID, projectID string) error {
@@ -694,10 +1146,12 @@ func startNode(nodeID, projectID string) error {
 	return nil
 }
 
-func createNode(nd NodeCreatePayload, projectID string) error {
+// createNode creates nd in GNS3 and returns the new node's ID.
+func createNode(nd NodeCreatePayload, projectID string) (string, error) {
 	var url string
 	var body []byte
 
+	x, y := nd.Position.XY()
 	switch nd.TemplateName {
 	case "cloud":
 		url = fmt.Sprintf("%s/v2/projects/%s/nodes", strings.TrimRight(gns3Server, "/"), projectID)
@@ -705,6 +1159,8 @@ func createNode(nd NodeCreatePayload, projectID string) error {
 			"name":       nd.Name,
 			"node_type":  "cloud",
 			"compute_id": "local",
+			"x":          x,
+			"y":          y,
 		})
 
 	case "ethernet_switch":
@@ -713,76 +1169,73 @@ func createNode(nd NodeCreatePayload, projectID string) error {
 			"name":       nd.Name,
 			"node_type":  "ethernet_switch",
 			"compute_id": "local",
+			"x":          x,
+			"y":          y,
 		})
 
-	case "qemu":
-		// Controller-level QEMU node creation
+	case "qemu", "docker", "iou", "dynamips":
+		// Controller-level node creation (QEMU routers, Docker hosts, IOU
+		// and Dynamips images)
+		kind := nd.TemplateName
 		url = fmt.Sprintf("%s/v2/projects/%s/nodes", strings.TrimRight(gns3Server, "/"), projectID)
 
 		// Step 1: Build payload
 		payload := map[string]interface{}{
 			"name":       nd.Name,
-			"node_type":  "qemu",
+			"node_type":  kind,
 			"compute_id": "local",
-			"properties": map[string]interface{}{
-				"adapter_type":   "e1000",
-				"adapters":       10,
-				"hda_disk_image": nd.Properties["image"],
-				"mac_address":    nd.Properties["mac_address"],
-				"ram":            2048,
-				"cpus":           2,
-				"platform":       "x86_64",
-				"console_type":   "telnet",
-			},
+			"properties": nd.Properties,
+			"x":          x,
+			"y":          y,
 		}
 
 		body, _ = json.Marshal(payload)
 
 		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 		if err != nil {
-			return fmt.Errorf("QEMU node POST failed: %v", err)
+			return "", fmt.Errorf("%s node POST failed: %v", kind, err)
 		}
 		defer resp.Body.Close()
 
 		data, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode >= 300 {
-			return fmt.Errorf("QEMU create API %d: %s", resp.StatusCode, data)
+			return "", fmt.Errorf("%s create API %d: %s", kind, resp.StatusCode, data)
 		}
 
 		var result map[string]interface{}
 		if err := json.Unmarshal(data, &result); err != nil {
-			return fmt.Errorf("decode QEMU create response: %v", err)
+			return "", fmt.Errorf("decode %s create response: %v", kind, err)
 		}
 
 		nodeID, ok := result["node_id"].(string)
 		if !ok || nodeID == "" {
 			nodeID, ok = result["id"].(string)
 			if !ok || nodeID == "" {
-				return fmt.Errorf("QEMU create: missing node_id in response: %s", data)
+				return "", fmt.Errorf("%s create: missing node_id in response: %s", kind, data)
 			}
 		}
 
-		// Step 2: Start the QEMU node
+		// Step 2: Start the node
 		startURL := fmt.Sprintf("%s/v2/projects/%s/nodes/%s/start", strings.TrimRight(gns3Server, "/"), projectID, nodeID)
 		startResp, err := http.Post(startURL, "application/json", nil)
 		if err != nil {
-			return fmt.Errorf("start QEMU node failed: %v", err)
+			return "", fmt.Errorf("start %s node failed: %v", kind, err)
 		}
 		defer startResp.Body.Close()
 
 		startData, _ := io.ReadAll(startResp.Body)
 		if startResp.StatusCode >= 300 {
-			return fmt.Errorf("start QEMU node API %d: %s", startResp.StatusCode, startData)
+			return "", fmt.Errorf("start %s node API %d: %s", kind, startResp.StatusCode, startData)
 		}
 
-		fmt.Printf("🚀 QEMU node %q created and started successfully.\n", nd.Name)
-		return nil
+		fmt.Printf("🚀 %s node %q created and started successfully.\n", kind, nd.Name)
+		return nodeID, nil
 
 	default:
 		// Template-based node
 		templates, err := listGlobalTemplates()
 		if err != nil {
-			return fmt.Errorf("listGlobalTemplates error: %v", err)
+			return "", fmt.Errorf("listGlobalTemplates error: %v", err)
 		}
 
 		var templateID string
@@ -793,67 +1246,106 @@ func createNode(nd NodeCreatePayload, projectID string) error {
 			}
 		}
 		if templateID == "" {
-			return fmt.Errorf("template %q doesn't exist", nd.TemplateName)
+			return "", fmt.Errorf("template %q doesn't exist", nd.TemplateName)
 		}
 
 		url = fmt.Sprintf("%s/v2/projects/%s/templates/%s", strings.TrimRight(gns3Server, "/"), projectID, templateID)
 		body, _ = json.Marshal(map[string]interface{}{
 			"name": nd.Name,
-			"x":    0,
-			"y":    0,
+			"x":    x,
+			"y":    y,
 		})
 
 		resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 		if err != nil {
-			return fmt.Errorf("template node POST failed: %v", err)
+			return "", fmt.Errorf("template node POST failed: %v", err)
 		}
 		defer resp.Body.Close()
 
 		data, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode >= 300 {
-			return fmt.Errorf("template-create API %d: %s", resp.StatusCode, data)
+			return "", fmt.Errorf("template-create API %d: %s", resp.StatusCode, data)
 		}
 
 		var node map[string]interface{}
 		if err := json.Unmarshal(data, &node); err != nil {
-			return fmt.Errorf("decode node JSON: %v", err)
+			return "", fmt.Errorf("decode node JSON: %v", err)
 		}
 		nodeID, _ := node["node_id"].(string)
 		if nodeID == "" {
 			nodeID, _ = node["id"].(string)
 		}
 		if nodeID == "" {
-			return fmt.Errorf("template node: missing node_id in response")
+			return "", fmt.Errorf("template node: missing node_id in response")
+		}
+		// Template nodes are named by the template's name format; give
+		// the node its topology name so it matches exactly.
+		if name, _ := node["name"].(string); name != nd.Name {
+			if err := renameNode(nodeID, projectID, nd.Name); err != nil {
+				return "", err
+			}
+		}
+
+		// IOU and Dynamips nodes override the template's properties
+		if len(nd.Properties) > 0 {
+			if err := updateNodeProperties(nodeID, projectID, nd.Properties); err != nil {
+				return "", err
+			}
 		}
 
 		// Start the template-based node
 		startURL := fmt.Sprintf("%s/v2/projects/%s/nodes/%s/start", strings.TrimRight(gns3Server, "/"), projectID, nodeID)
 		startResp, err := http.Post(startURL, "application/json", nil)
 		if err != nil {
-			return fmt.Errorf("template node start POST failed: %v", err)
+			return "", fmt.Errorf("template node start POST failed: %v", err)
 		}
 		defer startResp.Body.Close()
 		startData, _ := io.ReadAll(startResp.Body)
 		if startResp.StatusCode >= 300 {
-			return fmt.Errorf("template node start API %d: %s", startResp.StatusCode, startData)
+			return "", fmt.Errorf("template node start API %d: %s", startResp.StatusCode, startData)
 		}
 
 		fmt.Printf("🚀 Template node %q started successfully.\n", nd.Name)
-		return nil
+		return nodeID, nil
 	}
 
 	// Fallback (cloud/switch)
 	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("raw node POST failed: %v", err)
+		return "", fmt.Errorf("raw node POST failed: %v", err)
 	}
 	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode >= 300 {
-		data, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("raw node API %d: %s", resp.StatusCode, data)
+		return "", fmt.Errorf("raw node API %d: %s", resp.StatusCode, data)
+	}
+	var node ObservedNode
+	if err := json.Unmarshal(data, &node); err != nil || node.ID == "" {
+		return "", fmt.Errorf("raw node: missing node_id in response: %s", data)
 	}
 
 	fmt.Printf("✅ Raw node %q created.\n", nd.Name)
+	return node.ID, nil
+}
+
+// renameNode sets the name of a GNS3 node.
+func renameNode(nodeID, projectID, name string) error {
+	url := fmt.Sprintf("%s/v2/projects/%s/nodes/%s", strings.TrimRight(gns3Server, "/"), projectID, nodeID)
+	body, _ := json.Marshal(map[string]string{"name": name})
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("rename node PUT failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("rename node API %d: %s", resp.StatusCode, data)
+	}
 	return nil
 }
 
@@ -877,8 +1369,16 @@ func deleteLink(linkID, projectID string) error {
 		fmt.Sprintf("%s/v2/projects/%s/links/%s", strings.TrimRight(gns3Server, "/"), projectID, linkID),
 		nil,
 	)
-	_, err := http.DefaultClient.Do(req)
-	return err
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete link API %d: %s", resp.StatusCode, data)
+	}
+	return nil
 }
 
 func terraformDir(projectName string) string {
@@ -888,6 +1388,12 @@ func gns3NodeTypeToTF(nodeType string) string {
 	switch nodeType {
 	case "qemu":
 		return "gns3_qemu_node"
+	case "docker":
+		return "gns3_docker"
+	case "iou":
+		return "gns3_iou"
+	case "dynamips":
+		return "gns3_dynamips"
 	case "ethernet_switch":
 		return "gns3_switch"
 	case "cloud":
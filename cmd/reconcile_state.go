@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// reconcileState is what the reconciler remembers between passes, kept in
+// projects/<name>/reconcile-state.json. Nodes maps each topology node name
+// to the ID of the GNS3 node that implements it; only nodes in this map
+// are managed, so nodes added by hand in GNS3 are never touched.
+type reconcileState struct {
+	ProjectID string            `json:"project_id"`
+	Nodes     map[string]string `json:"nodes"` // topology name → GNS3 node ID
+}
+
+func reconcileStatePath(projectName string) string {
+	return filepath.Join("projects", projectName, "reconcile-state.json")
+}
+
+// loadReconcileState reads the state of a project. A missing file, or one
+// recorded for another GNS3 project ID, gives an empty state.
+func loadReconcileState(projectName, projectID string) (*reconcileState, error) {
+	s := &reconcileState{ProjectID: projectID, Nodes: make(map[string]string)}
+	data, err := os.ReadFile(reconcileStatePath(projectName))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading reconcile state: %w", err)
+	}
+	var saved reconcileState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid reconcile state %s: %w", reconcileStatePath(projectName), err)
+	}
+	if saved.ProjectID != projectID {
+		return s, nil
+	}
+	if saved.Nodes != nil {
+		s.Nodes = saved.Nodes
+	}
+	return s, nil
+}
+
+func (s *reconcileState) save(projectName string) error {
+	path := reconcileStatePath(projectName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// nodeMatch is the result of matching desired nodes to observed ones.
+type nodeMatch struct {
+	Matched   map[string]ObservedNode // desired name → its GNS3 node
+	ToAdd     []NodeCreatePayload
+	ToDelete  []ObservedNode // managed nodes no longer in the topology
+	Renamed   []ObservedNode // managed nodes renamed in GNS3; Matched has their topology name
+	Adopted   []string       // desired names newly matched by exact name
+	Unmanaged []ObservedNode // nodes the topology does not manage
+	Kept      []ObservedNode // deletions held back by the prune policy
+}
+
+// matchNodes pairs every desired node with a GNS3 node: first by the node
+// ID recorded in the state, then by exact name for nodes not yet recorded
+// (adopting nodes created by Terraform or an earlier version). It never
+// matches by prefix, so R1 cannot claim R10. The state is updated with the
+// adoptions and with recorded nodes that no longer exist.
+func matchNodes(desired []NodeCreatePayload, observed []ObservedNode, state *reconcileState) nodeMatch {
+	m := nodeMatch{Matched: make(map[string]ObservedNode)}
+	byID := make(map[string]ObservedNode, len(observed))
+	for _, o := range observed {
+		byID[o.ID] = o
+	}
+	managed := make(map[string]string, len(state.Nodes)) // ID → topology name
+	for name, id := range state.Nodes {
+		if _, ok := byID[id]; !ok {
+			delete(state.Nodes, name) // deleted outside the reconciler
+			continue
+		}
+		managed[id] = name
+	}
+
+	claimed := make(map[string]bool)
+	var pending []NodeCreatePayload
+	for _, d := range desired {
+		if id, ok := state.Nodes[d.Name]; ok {
+			o := byID[id]
+			m.Matched[d.Name] = o
+			claimed[id] = true
+			if o.Name != d.Name {
+				m.Renamed = append(m.Renamed, o)
+			}
+			continue
+		}
+		pending = append(pending, d)
+	}
+	for _, d := range pending {
+		for _, o := range observed {
+			if o.Name == d.Name && !claimed[o.ID] && managed[o.ID] == "" {
+				m.Matched[d.Name] = o
+				claimed[o.ID] = true
+				state.Nodes[d.Name] = o.ID
+				m.Adopted = append(m.Adopted, d.Name)
+				break
+			}
+		}
+		if _, ok := m.Matched[d.Name]; !ok {
+			m.ToAdd = append(m.ToAdd, d)
+		}
+	}
+
+	for _, o := range observed {
+		switch {
+		case claimed[o.ID]:
+		case managed[o.ID] != "":
+			m.ToDelete = append(m.ToDelete, o)
+		default:
+			m.Unmanaged = append(m.Unmanaged, o)
+		}
+	}
+	sort.Strings(m.Adopted)
+	return m
+}
+
+// nameToID maps the topology names of the matched nodes to their IDs.
+func (m nodeMatch) nameToID() map[string]string {
+	out := make(map[string]string, len(m.Matched))
+	for name, o := range m.Matched {
+		out[name] = o.ID
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMatchNodes(t *testing.T) {
+	nodes := func(names ...string) []NodeCreatePayload {
+		var out []NodeCreatePayload
+		for _, n := range names {
+			out = append(out, NodeCreatePayload{Name: n})
+		}
+		return out
+	}
+	names := func(obs []ObservedNode) []string {
+		var out []string
+		for _, o := range obs {
+			out = append(out, o.Name)
+		}
+		sort.Strings(out)
+		return out
+	}
+	tests := []struct {
+		name      string
+		desired   []NodeCreatePayload
+		observed  []ObservedNode
+		state     map[string]string
+		matched   map[string]string // desired name → observed ID
+		toAdd     []string
+		toDelete  []string
+		renamed   []string
+		adopted   []string
+		unmanaged []string
+		after     map[string]string // state after matching
+	}{
+		{
+			name:      "R1 does not claim R10",
+			desired:   nodes("R1"),
+			observed:  []ObservedNode{{ID: "id-10", Name: "R10"}},
+			state:     map[string]string{},
+			matched:   map[string]string{},
+			toAdd:     []string{"R1"},
+			unmanaged: []string{"R10"},
+			after:     map[string]string{},
+		},
+		{
+			name:     "R1 and R10 each adopt their own node",
+			desired:  nodes("R1", "R10"),
+			observed: []ObservedNode{{ID: "id-10", Name: "R10"}, {ID: "id-1", Name: "R1"}},
+			state:    map[string]string{},
+			matched:  map[string]string{"R1": "id-1", "R10": "id-10"},
+			adopted:  []string{"R1", "R10"},
+			after:    map[string]string{"R1": "id-1", "R10": "id-10"},
+		},
+		{
+			name:     "leaf-1 and leaf-2 do not collapse onto one node",
+			desired:  nodes("leaf-1", "leaf-2"),
+			observed: []ObservedNode{{ID: "id-a", Name: "leaf-1"}, {ID: "id-b", Name: "leaf-2"}},
+			state:    map[string]string{"leaf-2": "id-b"},
+			matched:  map[string]string{"leaf-1": "id-a", "leaf-2": "id-b"},
+			adopted:  []string{"leaf-1"},
+			after:    map[string]string{"leaf-1": "id-a", "leaf-2": "id-b"},
+		},
+		{
+			name:     "recorded ID wins over a rename in GNS3",
+			desired:  nodes("R1"),
+			observed: []ObservedNode{{ID: "id-1", Name: "R1-renamed"}},
+			state:    map[string]string{"R1": "id-1"},
+			matched:  map[string]string{"R1": "id-1"},
+			renamed:  []string{"R1-renamed"},
+			after:    map[string]string{"R1": "id-1"},
+		},
+		{
+			name:     "a managed node is not adopted by another name",
+			desired:  nodes("R2"),
+			observed: []ObservedNode{{ID: "id-1", Name: "R2"}},
+			state:    map[string]string{"R1": "id-1"},
+			matched:  map[string]string{},
+			toAdd:    []string{"R2"},
+			toDelete: []string{"R2"},
+			after:    map[string]string{"R1": "id-1"},
+		},
+		{
+			name:      "removed nodes are deleted, hand-made ones kept",
+			desired:   nodes("R1"),
+			observed:  []ObservedNode{{ID: "id-1", Name: "R1"}, {ID: "id-2", Name: "R2"}, {ID: "id-x", Name: "scratch"}},
+			state:     map[string]string{"R1": "id-1", "R2": "id-2"},
+			matched:   map[string]string{"R1": "id-1"},
+			toDelete:  []string{"R2"},
+			unmanaged: []string{"scratch"},
+			after:     map[string]string{"R1": "id-1", "R2": "id-2"},
+		},
+		{
+			name:    "nodes deleted outside the reconciler are forgotten",
+			desired: nodes("R1"),
+			state:   map[string]string{"R1": "id-gone"},
+			matched: map[string]string{},
+			toAdd:   []string{"R1"},
+			after:   map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &reconcileState{Nodes: tt.state}
+			m := matchNodes(tt.desired, tt.observed, state)
+
+			if got := m.nameToID(); !reflect.DeepEqual(got, tt.matched) {
+				t.Errorf("matched = %v, want %v", got, tt.matched)
+			}
+			var toAdd []string
+			for _, d := range m.ToAdd {
+				toAdd = append(toAdd, d.Name)
+			}
+			if !reflect.DeepEqual(toAdd, tt.toAdd) {
+				t.Errorf("ToAdd = %v, want %v", toAdd, tt.toAdd)
+			}
+			if got := names(m.ToDelete); !reflect.DeepEqual(got, tt.toDelete) {
+				t.Errorf("ToDelete = %v, want %v", got, tt.toDelete)
+			}
+			if got := names(m.Renamed); !reflect.DeepEqual(got, tt.renamed) {
+				t.Errorf("Renamed = %v, want %v", got, tt.renamed)
+			}
+			if !reflect.DeepEqual(m.Adopted, tt.adopted) {
+				t.Errorf("Adopted = %v, want %v", m.Adopted, tt.adopted)
+			}
+			if got := names(m.Unmanaged); !reflect.DeepEqual(got, tt.unmanaged) {
+				t.Errorf("Unmanaged = %v, want %v", got, tt.unmanaged)
+			}
+			if !reflect.DeepEqual(state.Nodes, tt.after) {
+				t.Errorf("state = %v, want %v", state.Nodes, tt.after)
+			}
+		})
+	}
+}
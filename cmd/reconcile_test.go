@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffLinks(t *testing.T) {
+	// desired and observed build a link between node/adapter/port pairs.
+	desired := func(a string, aa, ap int, b string, ba, bp int) LinkCreatePayload {
+		return LinkCreatePayload{Nodes: []linkEndpoint{
+			{NodeID: a, AdapterNumber: aa, PortNumber: ap},
+			{NodeID: b, AdapterNumber: ba, PortNumber: bp},
+		}}
+	}
+	observed := func(id, a string, aa, ap int, b string, ba, bp int) ObservedLink {
+		return ObservedLink{ID: id, Nodes: []ObservedLinkEndpoint{
+			{NodeID: a, AdapterNumber: aa, PortNumber: ap},
+			{NodeID: b, AdapterNumber: ba, PortNumber: bp},
+		}}
+	}
+	tests := []struct {
+		name     string
+		desired  []LinkCreatePayload
+		observed []ObservedLink
+		toAdd    []LinkCreatePayload
+		toDel    []string // observed link IDs
+	}{
+		{
+			name:     "in sync",
+			desired:  []LinkCreatePayload{desired("r1", 0, 0, "r2", 0, 0)},
+			observed: []ObservedLink{observed("l1", "r1", 0, 0, "r2", 0, 0)},
+		},
+		{
+			name:     "endpoints in the other order",
+			desired:  []LinkCreatePayload{desired("r1", 1, 0, "r2", 2, 0)},
+			observed: []ObservedLink{observed("l1", "r2", 2, 0, "r1", 1, 0)},
+		},
+		{
+			name:     "cable moved to another port",
+			desired:  []LinkCreatePayload{desired("r1", 2, 0, "r2", 0, 0)},
+			observed: []ObservedLink{observed("l1", "r1", 1, 0, "r2", 0, 0)},
+			toAdd:    []LinkCreatePayload{desired("r1", 2, 0, "r2", 0, 0)},
+			toDel:    []string{"l1"},
+		},
+		{
+			name: "parallel links do not collapse into one",
+			desired: []LinkCreatePayload{
+				desired("r1", 0, 0, "r2", 0, 0),
+				desired("r1", 1, 0, "r2", 1, 0),
+			},
+			observed: []ObservedLink{observed("l1", "r1", 0, 0, "r2", 0, 0)},
+			toAdd:    []LinkCreatePayload{desired("r1", 1, 0, "r2", 1, 0)},
+		},
+		{
+			name:    "parallel link removed",
+			desired: []LinkCreatePayload{desired("r1", 0, 0, "r2", 0, 0)},
+			observed: []ObservedLink{
+				observed("l1", "r1", 0, 0, "r2", 0, 0),
+				observed("l2", "r1", 1, 0, "r2", 1, 0),
+			},
+			toDel: []string{"l2"},
+		},
+		{
+			name:     "links without two ends are ignored",
+			desired:  []LinkCreatePayload{{Nodes: []linkEndpoint{{NodeID: "r1"}}}},
+			observed: []ObservedLink{{ID: "l1", Nodes: []ObservedLinkEndpoint{{NodeID: "r1"}}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toAdd, toDel := diffLinks(tt.desired, tt.observed)
+			if !reflect.DeepEqual(toAdd, tt.toAdd) {
+				t.Errorf("toAdd = %v, want %v", toAdd, tt.toAdd)
+			}
+			var ids []string
+			for _, l := range toDel {
+				ids = append(ids, l.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.toDel) {
+				t.Errorf("toDel = %v, want %v", ids, tt.toDel)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+var schemaOutput string
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the topology format",
+	Long: `Print the JSON Schema that topology files are validated against. Point
+yaml-language-server at it for editor validation and autocompletion, e.g.
+
+  netdevops schema -o topology.schema.json
+  # yaml-language-server: $schema=./topology.schema.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if schemaOutput == "" {
+			_, err := os.Stdout.Write(topology.Schema)
+			return err
+		}
+		if err := os.WriteFile(schemaOutput, topology.Schema, 0644); err != nil {
+			return fmt.Errorf("could not write schema to %s: %w", schemaOutput, err)
+		}
+		fmt.Println("✅ Schema written to", schemaOutput)
+		return nil
+	},
+}
+
+func init() {
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "write the schema to this file instead of stdout")
+	rootCmd.AddCommand(schemaCmd)
+}
@@ -19,7 +19,7 @@ resource "gns3_project" "project1" {
 
 # --- Unique GNS3 Template Lookups (for routers & servers) ---
 {{- range $templateName, $_ := .UniqueTemplateNames }}
-data "gns3_template_id" "{{ $templateName }}" {
+data "gns3_template_id" "{{ tfName $templateName }}" {
   name = "{{ $templateName }}"
 }
 {{- end }}
@@ -29,7 +29,9 @@ data "gns3_template_id" "{{ $templateName }}" {
 resource "gns3_template" "{{ .Name }}" {
   name        = "{{ .Name }}"
   project_id  = gns3_project.project1.id
-  template_id = data.gns3_template_id.{{ .TemplateName }}.template_id
+  x           = {{ .X }}
+  y           = {{ .Y }}
+  template_id = data.gns3_template_id.{{ tfName .TemplateName }}.template_id
   start       = {{ if .Start }}{{ .Start }}{{ else }}true{{ end }}
 }
 data "gns3_node_id" "{{ .Name }}" {
@@ -44,7 +46,9 @@ data "gns3_node_id" "{{ .Name }}" {
 resource "gns3_template" "{{ .Name }}" {
   name        = "{{ .Name }}"
   project_id  = gns3_project.project1.id
-  template_id = data.gns3_template_id.{{ .Name }}.template_id
+  x           = {{ .X }}
+  y           = {{ .Y }}
+  template_id = data.gns3_template_id.{{ tfName .Name }}.template_id
   start       = {{ if .Start }}{{ .Start }}{{ else }}true{{ end }}
 }
 data "gns3_node_id" "{{ .Name }}" {
@@ -58,14 +62,20 @@ data "gns3_node_id" "{{ .Name }}" {
 {{- range .Topology.NetworkDevice.Routers }}
 resource "gns3_qemu_node" "{{ .Name }}" {
   project_id     = gns3_project.project1.id
+  x              = {{ .X }}
+  y              = {{ .Y }}
   name           = "{{ .Name }}"
-  adapter_type   = "e1000"
-  adapters       = 10
+  adapter_type   = "{{ .AdapterType }}"
+  adapters       = {{ .Adapters }}
   hda_disk_image = "{{ .Image }}"
   mac_address    = "{{ .MacAddress }}"
-  cpus           = 2
-  ram            = 2056
-  platform       = "x86_64"
+  cpus           = {{ .CPUs }}
+  ram            = {{ .RAM }}
+  platform       = "{{ .Platform }}"
+  console_type   = "{{ .ConsoleType }}"
+{{- if .Options }}
+  options        = "{{ hcl .Options }}"
+{{- end }}
   start_vm       = true
 }
 data "gns3_node_id" "{{ .Name }}" {
@@ -80,6 +90,8 @@ data "gns3_node_id" "{{ .Name }}" {
 resource "gns3_switch" "{{ .Name }}" {
   name       = "{{ .Name }}"
   project_id = gns3_project.project1.id
+  x          = {{ .X }}
+  y          = {{ .Y }}
 }
 data "gns3_node_id" "{{ .Name }}" {
   project_id = gns3_project.project1.id
@@ -93,6 +105,8 @@ data "gns3_node_id" "{{ .Name }}" {
 resource "gns3_cloud" "{{ .Name }}" {
   name       = "{{ .Name }}"
   project_id = gns3_project.project1.id
+  x          = {{ .X }}
+  y          = {{ .Y }}
 }
 data "gns3_node_id" "{{ .Name }}" {
   project_id = gns3_project.project1.id
@@ -101,9 +115,106 @@ data "gns3_node_id" "{{ .Name }}" {
 }
 {{- end }}
 
+# --- Docker Hosts ---
+{{- range .Topology.Docker }}
+resource "gns3_docker" "{{ .Name }}" {
+  name         = "{{ .Name }}"
+  project_id   = gns3_project.project1.id
+  x            = {{ .X }}
+  y            = {{ .Y }}
+  image        = "{{ .Image }}"
+  adapters     = {{ .Adapters }}
+  console_type = "{{ .ConsoleType }}"
+{{- if .StartCommand }}
+  start_command = "{{ hcl .StartCommand }}"
+{{- end }}
+{{- if .Environment }}
+  environment = {
+{{- range $k, $v := .Environment }}
+    "{{ hcl $k }}" = "{{ hcl $v }}"
+{{- end }}
+  }
+{{- end }}
+}
+data "gns3_node_id" "{{ .Name }}" {
+  project_id = gns3_project.project1.id
+  name       = "{{ .Name }}"
+  depends_on = [gns3_docker.{{ .Name }}]
+}
+{{- end }}
+
+# --- IOU Nodes ---
+{{- range .IOUNodes }}
+resource "gns3_iou" "{{ .Name }}" {
+  name              = "{{ .Name }}"
+  project_id        = gns3_project.project1.id
+  x                 = {{ .X }}
+  y                 = {{ .Y }}
+  path              = "{{ .Image }}"
+  ram               = {{ .RAM }}
+  nvram             = {{ .NVRAM }}
+  ethernet_adapters = {{ .EthernetAdapters }}
+  serial_adapters   = {{ .SerialAdapters }}
+}
+data "gns3_node_id" "{{ .Name }}" {
+  project_id = gns3_project.project1.id
+  name       = "{{ .Name }}"
+  depends_on = [gns3_iou.{{ .Name }}]
+}
+{{- end }}
+
+# --- Dynamips Routers ---
+{{- range .DynamipsNodes }}
+resource "gns3_dynamips" "{{ .Name }}" {
+  name       = "{{ .Name }}"
+  project_id = gns3_project.project1.id
+  x          = {{ .X }}
+  y          = {{ .Y }}
+  platform   = "{{ .Platform }}"
+  image      = "{{ .Image }}"
+{{- if .RAM }}
+  ram        = {{ .RAM }}
+{{- end }}
+{{- if .NVRAM }}
+  nvram      = {{ .NVRAM }}
+{{- end }}
+{{- range $i, $m := .Slots }}{{ if $m }}
+  slot{{ $i }}      = "{{ hcl $m }}"
+{{- end }}{{ end }}
+{{- range $i, $m := .WICs }}{{ if $m }}
+  wic{{ $i }}       = "{{ hcl $m }}"
+{{- end }}{{ end }}
+{{- if .IdlePC }}
+  idlepc     = "{{ hcl .IdlePC }}"
+{{- end }}
+}
+data "gns3_node_id" "{{ .Name }}" {
+  project_id = gns3_project.project1.id
+  name       = "{{ .Name }}"
+  depends_on = [gns3_dynamips.{{ .Name }}]
+}
+{{- end }}
+
+# --- Template Nodes (VPCS, NAT, hubs, IOU and Dynamips templates) ---
+{{- range .TemplateNodes }}
+resource "gns3_template" "{{ .Name }}" {
+  name        = "{{ .Name }}"
+  project_id  = gns3_project.project1.id
+  x           = {{ .X }}
+  y           = {{ .Y }}
+  template_id = data.gns3_template_id.{{ tfName .TemplateName }}.template_id
+  start       = true
+}
+data "gns3_node_id" "{{ .Name }}" {
+  project_id = gns3_project.project1.id
+  name       = "{{ .Name }}"
+  depends_on = [gns3_template.{{ .Name }}]
+}
+{{- end }}
+
 # --- Links ---
 {{- range .Topology.Links }}
-resource "gns3_link" "{{ (index .Endpoints 0).Name }}_to_{{ (index .Endpoints 1).Name }}" {
+resource "gns3_link" "{{ .ResourceName }}" {
   lifecycle {
     create_before_destroy = true
   }
@@ -116,6 +227,12 @@ resource "gns3_link" "{{ (index .Endpoints 0).Name }}_to_{{ (index .Endpoints 1)
   node_b_port    = {{ (index .Endpoints 1).Port }}
 }
 {{- end }}
+{{- range .LinkMoves }}
+moved {
+  from = gns3_link.{{ .From }}
+  to   = gns3_link.{{ .To }}
+}
+{{- end }}
 
 {{ if .Topology.Project.StartNodes }}
 resource "gns3_start_all" "start_nodes" {
@@ -131,7 +248,15 @@ resource "gns3_start_all" "start_nodes" {
     {{- end }}
     {{- range .Topology.Clouds }}gns3_cloud.{{ .Name }},
     {{- end }}
-    {{- range .Topology.Links }}gns3_link.{{ (index .Endpoints 0).Name }}_to_{{ (index .Endpoints 1).Name }},
+    {{- range .Topology.Docker }}gns3_docker.{{ .Name }},
+    {{- end }}
+    {{- range .IOUNodes }}gns3_iou.{{ .Name }},
+    {{- end }}
+    {{- range .DynamipsNodes }}gns3_dynamips.{{ .Name }},
+    {{- end }}
+    {{- range .TemplateNodes }}gns3_template.{{ .Name }},
+    {{- end }}
+    {{- range .Topology.Links }}gns3_link.{{ .ResourceName }},
     {{- end }}
   ]
 }
@@ -4,66 +4,32 @@ import (
 	"fmt"
 	"strings"
 	"time"
-)
-
-// Topology represents the complete network topology shared between CLI and YAML modes.
-type Topology struct {
-	Project struct {
-		Name             string `yaml:"name"`
-		StartNodes       bool   `yaml:"start_nodes"`
-		GNS3Server       string `yaml:"gns3_server"`
-		TerraformVersion string `yaml:"terraform_version"`
-	} `yaml:"project"`
-
-	NetworkDevice struct {
-		Routers []NetworkDevice `yaml:"routers"`
-	} `yaml:"network-device"`
-
-	Switches  []Switch      `yaml:"switches"`
-	Clouds    []Cloud       `yaml:"clouds"`
-	Templates TemplateGroup `yaml:"templates"`
-	Links     []Link        `yaml:"links"`
 
-	ZTPServer        string            `yaml:"-"` // Extracted from ztp-server in templates
-	LinkIDs          map[string]string `yaml:"-"`
-	NetworkDeviceIDs map[string]string `yaml:"-"`
-}
-
-func (cl *ConfigList) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	var list []*ConfigBlock
-	if err := unmarshal(&list); err == nil {
-		*cl = list
-		return nil
-	}
-	var single ConfigBlock
-	if err := unmarshal(&single); err != nil {
-		return err
-	}
-	*cl = []*ConfigBlock{&single}
-	return nil
-}
-
-type NetworkDevice struct {
-	Name       string      `yaml:"name"`
-	Hostname   string      `yaml:"hostname"`
-	Vendor     string      `yaml:"vendor"`
-	MacAddress string      `yaml:"mac_address"`
-	Image      string      `yaml:"image"`
-	Config     interface{} `yaml:"config"`
-	Port       int         `yaml:"-"`
-}
+	"netdevops-cli-tool/internal/topology"
+)
 
-type TemplateGroup struct {
-	Servers []TemplateServer `yaml:"servers"`
-	Routers []Router         `yaml:"routers"`
-}
+// The topology model lives in internal/topology so every command decodes
+// the YAML the same way; these aliases keep the cmd package readable.
+type (
+	Topology       = topology.Topology
+	NetworkDevice  = topology.NetworkDevice
+	TemplateGroup  = topology.TemplateGroup
+	TemplateServer = topology.TemplateServer
+	Router         = topology.Router
+	Switch         = topology.Switch
+	Cloud          = topology.Cloud
+	DockerNode     = topology.DockerNode
+	TemplateNode   = topology.TemplateNode
+	IOUNode        = topology.IOUNode
+	DynamipsNode   = topology.DynamipsNode
+	Endpoint       = topology.Endpoint
+	Link           = topology.Link
+	ConfigList     = topology.ConfigList
+	Position       = topology.Position
+	ConfigBlock    = topology.ConfigBlock
+	Redistribution = topology.Redistribution
+)
 
-type TemplateServer struct {
-	Name         string `yaml:"name"`
-	TemplateName string `yaml:"template_name"`
-	Start        bool   `yaml:"start"`
-	ZTPServer    string `yaml:"ztp_server,omitempty"` // Only applicable to ztp-server
-}
 type TemplateData struct {
 	Templates struct {
 		Servers []TemplateServer
@@ -72,38 +38,6 @@ type TemplateData struct {
 	UniqueRouterTemplates map[string]bool // e.g. {"arista-eos":true}
 }
 
-// Router defines a router device.
-type Router struct {
-	Name         string     `yaml:"name"`
-	Vendor       string     `yaml:"vendor"` // Added to support YAML input (e.g., "arista")
-	Template     string     `yaml:"template"`
-	Config       ConfigList `yaml:"config"`
-	Start        bool       `yaml:"start"`
-	TemplateName string     `yaml:"template_name"`
-}
-
-// Switch defines a switch device.
-type Switch struct {
-	Name string `yaml:"name"`
-}
-
-// Cloud defines a cloud device.
-type Cloud struct {
-	Name string `yaml:"name"`
-}
-
-// Endpoint defines a device interface, including adapter and port numbers.
-type Endpoint struct {
-	Name    string `yaml:"name"`
-	Adapter int    `yaml:"adapter"`
-	Port    int    `yaml:"port"`
-}
-
-// Link defines a connection between two endpoints.
-type Link struct {
-	Endpoints []Endpoint `yaml:"endpoints"`
-}
-
 // CLILink is an alias for Link, used in CLI mode.
 type CLILink = Link
 
@@ -158,18 +92,6 @@ type BGPConfig struct {
 	Redistribute []Redistribution
 }
 
-// Redistribution represents a redistribution rule.
-type Redistribution struct {
-	Protocol  string `yaml:"protocol"`
-	Metric    int    `yaml:"metric,omitempty"`
-	RouteMap  string `yaml:"route_map,omitempty"`
-	IsisLevel string `yaml:"isis_level,omitempty"` // Optional; if provided, passed to module
-	OspfRoute string `yaml:"ospf_route,omitempty"`
-}
-
-// ConfigList is a list of configuration blocks, supporting a single block or a list.
-type ConfigList []*ConfigBlock
-
 // Deployment holds the deployment configuration from YAML.
 type Deployment struct {
 	Project struct {
@@ -226,6 +148,7 @@ var (
 
 const (
 	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
 	colorYellow = "\x1b[33m"
 	colorCyan   = "\x1b[36m"
 	colorReset  = "\x1b[0m"
@@ -251,8 +174,9 @@ func prettyYAMLErrors(err error) {
 
 var (
 	gns3Server        string
-	reconcileInterval = 30 * time.Second
+	reconcileInterval = 30 * time.Second // default time between reconcile passes
 	detach            bool
+	reconcileOpts     ReconcileOptions
 )
 
 // RawLink is built directly from your YAML (uses node names).
@@ -279,6 +203,7 @@ type NodeCreatePayload struct {
 	Properties   map[string]interface{} `json:"properties,omitempty"`
 	TemplateName string                 `json:"-"`
 	ResourceType string
+	Position     Position `json:"-"` // canvas position
 }
 type linkEndpoint struct {
 	NodeName      string `json:"node_name,omitempty"`
@@ -294,10 +219,12 @@ type LinkCreatePayload struct {
 // ─── Observed (GNS3 API) ──────────────────────────────────────────────────────
 
 type ObservedNode struct {
-	ID           string `json:"node_id"`
-	Name         string `json:"name"`
-	Status       string `json:"status"`
-	NodeType     string `json:"node_type"`
+	ID           string                 `json:"node_id"`
+	Name         string                 `json:"name"`
+	Status       string                 `json:"status"`
+	NodeType     string                 `json:"node_type"`
+	ConsoleType  string                 `json:"console_type"`
+	Properties   map[string]interface{} `json:"properties"`
 	ResourceType string
 }
 
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
 	"text/template"
+
+	"netdevops-cli-tool/internal/topology"
 )
 
 // funcMap holds custom template functions for Terraform rendering.
@@ -15,6 +18,30 @@ var funcMap = template.FuncMap{
 	"multiply": multiply,
 	"mod":      mod,
 	"add":      func(a, b int) int { return a + b },
+	"tfName":   tfName,
+	"hcl":      hclEscape,
+}
+
+// loadTopology reads a topology file through the shared loader and
+// pretty-prints YAML decoding errors before returning them.
+func loadTopology(path string) (Topology, error) {
+	t, err := topology.Load(path)
+	var perr *topology.ParseError
+	if errors.As(err, &perr) {
+		prettyYAMLErrors(perr)
+	}
+	return t, err
+}
+
+// loadTopologyReadOnly is loadTopology for commands that only inspect the
+// topology: IPAM addresses come from the lockfile, which is left as is.
+func loadTopologyReadOnly(path string) (Topology, error) {
+	t, err := topology.LoadReadOnly(path)
+	var perr *topology.ParseError
+	if errors.As(err, &perr) {
+		prettyYAMLErrors(perr)
+	}
+	return t, err
 }
 
 // Change function signature
@@ -31,6 +58,38 @@ func runCommandInDir(cmdName string, args []string, dir string, logFile *os.File
 	return cmd.Run()
 }
 
+// tfName turns a GNS3 name such as "Ethernet hub" into a valid Terraform
+// identifier.
+func tfName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case (r >= '0' && r <= '9') || r == '-':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// hclEscape escapes s for use inside a quoted HCL string, so quotes,
+// backslashes and ${ or %{ in user input stay literal.
+var hclEscape = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"${", "$${",
+	"%{", "%%{",
+).Replace
+
 // multiply returns the product of two integers.
 func multiply(a, b int) int {
 	return a * b
@@ -0,0 +1,21 @@
+package cmd
+
+import "testing"
+
+func TestHCLEscape(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`sleep infinity`, `sleep infinity`},
+		{`sh -c "sleep infinity"`, `sh -c \"sleep infinity\"`},
+		{`C:\images`, `C:\\images`},
+		{`echo ${HOME}`, `echo $${HOME}`},
+		{`%{ if true }`, `%%{ if true }`},
+		{"a\nb\tc", `a\nb\tc`},
+		{`$HOME 50%`, `$HOME 50%`},
+		{`-smbios type=1,product="vMX"`, `-smbios type=1,product=\"vMX\"`},
+	}
+	for _, tt := range tests {
+		if got := hclEscape(tt.in); got != tt.want {
+			t.Errorf("hclEscape(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
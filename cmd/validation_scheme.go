@@ -3,7 +3,13 @@ package cmd
 
 import (
 	"fmt"
+	"net"
+	"path"
+	"slices"
+	"sort"
 	"strings"
+
+	"netdevops-cli-tool/internal/topology"
 )
 
 // validateTopology walks your Topology struct and accumulates any errors.
@@ -17,63 +23,56 @@ func validateTopology(t *Topology) error {
 	if t.Project.TerraformVersion == "" {
 		errs = append(errs, "project.terraform_version is required")
 	}
+	if t.Project.MACPrefix != "" {
+		if _, err := topology.ParseMACPrefix(t.Project.MACPrefix); err != nil {
+			errs = append(errs, fmt.Sprintf("project.mac_prefix: %v", err))
+		}
+	}
 
-	// Routers
-	if len(t.NetworkDevice.Routers) == 0 {
-		errs = append(errs, "network-device.routers must contain at least one router")
-	} else {
-		for i, r := range t.NetworkDevice.Routers {
-			p := fmt.Sprintf("network-device.routers[%d]", i)
-			if r.Name == "" {
-				errs = append(errs, p+".name is required")
-			}
-			if r.Hostname == "" {
-				errs = append(errs, p+".hostname is required")
-			}
-			switch strings.ToLower(r.Vendor) {
-			case "arista", "cisco", "juniper":
-			default:
-				errs = append(errs, p+".vendor must be one of arista,cisco,juniper")
-			}
-			if r.MacAddress == "" {
-				errs = append(errs, p+".mac_address is required")
-			}
-			if r.Image == "" {
-				errs = append(errs, p+".image is required")
-			}
-
-			// r.Config is interface{} → assert to []interface{} before len()
-			rawCfg := r.Config
-			cfgSlice, ok := rawCfg.([]interface{})
-			if !ok {
-				errs = append(errs, p+".config is not an array")
-			} else if len(cfgSlice) == 0 {
-				errs = append(errs, p+".config must have at least one entry")
-			}
+	// Routers. At least one is required, from either section. Servers,
+	// links and router config may be empty, as in the schema: a lab needs
+	// no ZTP server, a single router has nothing to cable, and IPAM can
+	// number a router with no config.
+	if len(t.NetworkDevice.Routers) == 0 && len(t.Templates.Routers) == 0 {
+		errs = append(errs, "network-device.routers or templates.routers must contain at least one router")
+	}
+	for i, r := range t.NetworkDevice.Routers {
+		p := fmt.Sprintf("network-device.routers[%d]", i)
+		if r.Name == "" {
+			errs = append(errs, p+".name is required")
+		}
+		switch strings.ToLower(r.Vendor) {
+		case "arista", "cisco", "juniper":
+		default:
+			errs = append(errs, p+".vendor must be one of arista,cisco,juniper")
+		}
+		if r.Image == "" {
+			errs = append(errs, p+".image is required")
+		}
+	}
+	for i, r := range t.Templates.Routers {
+		p := fmt.Sprintf("templates.routers[%d]", i)
+		if r.Name == "" {
+			errs = append(errs, p+".name is required")
+		}
+		if r.TemplateName == "" {
+			errs = append(errs, p+".template_name is required")
 		}
 	}
 
 	// Templates → Servers
-	if len(t.Templates.Servers) == 0 {
-		errs = append(errs, "templates.servers must contain at least one entry")
-	} else {
-		for i, srv := range t.Templates.Servers {
-			p := fmt.Sprintf("templates.servers[%d]", i)
-			if srv.Name == "" {
-				errs = append(errs, p+".name is required")
-			}
+	for i, srv := range t.Templates.Servers {
+		p := fmt.Sprintf("templates.servers[%d]", i)
+		if srv.Name == "" {
+			errs = append(errs, p+".name is required")
 		}
 	}
 
 	// Links
-	if len(t.Links) == 0 {
-		errs = append(errs, "links must contain at least one link")
-	} else {
-		for i, link := range t.Links {
-			p := fmt.Sprintf("links[%d].endpoints", i)
-			if len(link.Endpoints) < 2 {
-				errs = append(errs, p+" must have at least two endpoints")
-			}
+	for i, link := range t.Links {
+		p := fmt.Sprintf("links[%d].endpoints", i)
+		if len(link.Endpoints) != 2 {
+			errs = append(errs, p+" must have exactly two endpoints")
 		}
 	}
 
@@ -89,8 +88,335 @@ func validateTopology(t *Topology) error {
 		}
 	}
 
+	// Docker hosts & template nodes
+	for i, d := range t.Docker {
+		p := fmt.Sprintf("docker[%d]", i)
+		if d.Name == "" {
+			errs = append(errs, p+".name is required")
+		}
+		if d.Image == "" {
+			errs = append(errs, p+".image is required")
+		}
+	}
+	for _, sec := range templateSections(t) {
+		for i, n := range sec.Nodes {
+			p := fmt.Sprintf("%s[%d]", sec.Key, i)
+			if n.Name == "" {
+				errs = append(errs, p+".name is required")
+			}
+			if n.TemplateName == "" {
+				errs = append(errs, p+".template_name is required")
+			}
+		}
+	}
+	for i, n := range t.IOU {
+		p := fmt.Sprintf("iou[%d]", i)
+		if n.Name == "" {
+			errs = append(errs, p+".name is required")
+		}
+		if n.Image == "" && n.TemplateName == "" {
+			errs = append(errs, p+": image or template_name is required")
+		}
+	}
+	for i, n := range t.Dynamips {
+		p := fmt.Sprintf("dynamips[%d]", i)
+		if n.Name == "" {
+			errs = append(errs, p+".name is required")
+		}
+		if n.Image == "" && n.TemplateName == "" {
+			errs = append(errs, p+": image or template_name is required")
+		}
+		if n.Platform != "" && !slices.Contains(dynamipsPlatforms, n.Platform) {
+			errs = append(errs, fmt.Sprintf("%s.platform must be one of %s", p, strings.Join(dynamipsPlatforms, ",")))
+		} else if n.Image != "" && n.Platform == "" {
+			errs = append(errs, p+".platform is required with image")
+		}
+	}
+
+	errs = append(errs, validateSemantics(t)...)
+
 	if len(errs) > 0 {
-		return fmt.Errorf("validation failed:\n - %s", strings.Join(errs, "\n - "))
+		return &validationError{Errs: errs}
 	}
 	return nil
 }
+
+// validationError lists every problem validateTopology found, each
+// prefixed with its path in the file.
+type validationError struct {
+	Errs []string
+}
+
+func (e *validationError) Error() string {
+	return fmt.Sprintf("validation failed:\n - %s", strings.Join(e.Errs, "\n - "))
+}
+
+// topoNode is what the semantic checks need to know about a declared node.
+type topoNode struct {
+	Path     string // YAML path of the declaration, e.g. switches[0]
+	Vendor   string
+	Platform string   // interface naming scheme, see topology.Platforms
+	Adapters int      // 0 when the adapter count is unknown (template nodes)
+	Slots    []string // network module per adapter of a Dynamips router
+	Config   ConfigList
+}
+
+// dynamipsPlatforms are the router platforms Dynamips emulates.
+var dynamipsPlatforms = []string{"c7200", "c3745", "c3725", "c3600", "c2691", "c2600", "c1700"}
+
+// templateSection is one of the top-level template node lists.
+type templateSection struct {
+	Key   string
+	Nodes []TemplateNode
+}
+
+func templateSections(t *Topology) []templateSection {
+	return []templateSection{
+		{"vpcs", t.VPCS},
+		{"nat", t.NAT},
+		{"hubs", t.Hubs},
+	}
+}
+
+// indexNodes maps every declared node name to its declaration and reports
+// names that are declared more than once, in any section.
+func indexNodes(t *Topology) (map[string]topoNode, []string) {
+	nodes := make(map[string]topoNode)
+	platforms := t.Platforms()
+	var errs []string
+	add := func(name string, n topoNode) {
+		if name == "" {
+			return
+		}
+		n.Platform = platforms[name]
+		if prev, dup := nodes[name]; dup {
+			errs = append(errs, fmt.Sprintf("%s.name: duplicate node name %q (already declared at %s)", n.Path, name, prev.Path))
+			return
+		}
+		nodes[name] = n
+	}
+
+	for i, r := range t.NetworkDevice.Routers {
+		add(r.Name, topoNode{
+			Path:     fmt.Sprintf("network-device.routers[%d]", i),
+			Vendor:   r.Vendor,
+			Adapters: r.Adapters,
+			Config:   r.Config,
+		})
+	}
+	for i, r := range t.Templates.Routers {
+		add(r.Name, topoNode{
+			Path:   fmt.Sprintf("templates.routers[%d]", i),
+			Vendor: r.Vendor,
+			Config: r.Config,
+		})
+	}
+	for i, s := range t.Templates.Servers {
+		add(s.Name, topoNode{Path: fmt.Sprintf("templates.servers[%d]", i)})
+	}
+	for i, s := range t.Switches {
+		add(s.Name, topoNode{Path: fmt.Sprintf("switches[%d]", i), Adapters: 1})
+	}
+	for i, c := range t.Clouds {
+		add(c.Name, topoNode{Path: fmt.Sprintf("clouds[%d]", i)})
+	}
+	for i, d := range t.Docker {
+		add(d.Name, topoNode{Path: fmt.Sprintf("docker[%d]", i), Adapters: d.Adapters})
+	}
+	for _, sec := range templateSections(t) {
+		for i, n := range sec.Nodes {
+			tn := topoNode{Path: fmt.Sprintf("%s[%d]", sec.Key, i)}
+			switch n.Kind {
+			case "vpcs", "nat", "ethernet_hub":
+				tn.Adapters = 1
+			}
+			add(n.Name, tn)
+		}
+	}
+	for i, n := range t.IOU {
+		add(n.Name, topoNode{Path: fmt.Sprintf("iou[%d]", i), Adapters: n.Adapters()})
+	}
+	for i, n := range t.Dynamips {
+		add(n.Name, topoNode{Path: fmt.Sprintf("dynamips[%d]", i), Adapters: len(n.Slots), Slots: n.Slots})
+	}
+	return nodes, errs
+}
+
+// validateSemantics catches the mistakes that otherwise only surface at
+// Terraform apply time or in the reconciler: names, links, ports, addresses
+// and the project's reconcile settings that the schema cannot check.
+func validateSemantics(t *Topology) []string {
+	nodes, errs := indexNodes(t)
+
+	// MAC addresses
+	macs := make(map[string]string)
+	for i, r := range t.NetworkDevice.Routers {
+		if r.MacAddress == "" {
+			continue
+		}
+		p := fmt.Sprintf("network-device.routers[%d].mac_address", i)
+		hw, err := net.ParseMAC(r.MacAddress)
+		if err != nil || len(hw) != 6 {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a valid MAC address", p, r.MacAddress))
+			continue
+		}
+		if prev, dup := macs[hw.String()]; dup {
+			errs = append(errs, fmt.Sprintf("%s: MAC address %s is already used by %s", p, hw, prev))
+			continue
+		}
+		macs[hw.String()] = r.Name
+	}
+
+	// Prune protection patterns
+	for i, pat := range t.Project.Prune.Protect {
+		if _, err := path.Match(pat, ""); err != nil {
+			errs = append(errs, fmt.Sprintf("project.prune.protect[%d]: %q is not a valid name pattern", i, pat))
+		}
+	}
+
+	// Drift overrides
+	overridden := make([]string, 0, len(t.Project.Drift))
+	for field := range t.Project.Drift {
+		overridden = append(overridden, field)
+	}
+	sort.Strings(overridden)
+	for _, field := range overridden {
+		p := "project.drift." + field
+		if !isDriftField(field) {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a node property the reconciler compares", p, field))
+			continue
+		}
+		switch policy := t.Project.Drift[field]; policy {
+		case driftUpdate, driftRecreate, driftWarn:
+		default:
+			errs = append(errs, fmt.Sprintf("%s: %q is not a drift policy (update, recreate or warn)", p, policy))
+		}
+	}
+
+	// Links
+	type portKey struct {
+		node          string
+		adapter, port int
+	}
+	usedBy := make(map[portKey]string)
+	for i, link := range t.Links {
+		for j, ep := range link.Endpoints {
+			p := fmt.Sprintf("links[%d].endpoints[%d]", i, j)
+			n, ok := nodes[ep.Name]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("%s.name: node %q is not defined", p, ep.Name))
+				continue
+			}
+			if ep.Interface != "" {
+				adapter, port, err := topology.InterfacePort(n.Platform, ep.Interface)
+				if err != nil {
+					errs = append(errs, fmt.Sprintf("%s.interface: %v", p, err))
+					continue
+				}
+				if adapter != ep.Adapter || port != ep.Port {
+					errs = append(errs, fmt.Sprintf("%s.interface: %s %s is adapter %d port %d, but adapter %d port %d is given",
+						p, ep.Name, ep.Interface, adapter, port, ep.Adapter, ep.Port))
+					continue
+				}
+			}
+			if n.Adapters > 0 && ep.Adapter >= n.Adapters {
+				errs = append(errs, fmt.Sprintf("%s.adapter: %s has %d adapters (0-%d), got %d",
+					p, ep.Name, n.Adapters, n.Adapters-1, ep.Adapter))
+			} else if ep.Adapter < len(n.Slots) && n.Slots[ep.Adapter] == "" {
+				errs = append(errs, fmt.Sprintf("%s.adapter: %s has no network module in slot %d", p, ep.Name, ep.Adapter))
+			}
+			k := portKey{ep.Name, ep.Adapter, ep.Port}
+			if prev, dup := usedBy[k]; dup {
+				errs = append(errs, fmt.Sprintf("%s: %s adapter %d port %d is already cabled by %s",
+					p, ep.Name, ep.Adapter, ep.Port, prev))
+				continue
+			}
+			usedBy[k] = fmt.Sprintf("links[%d]", i)
+		}
+
+		if len(link.Endpoints) == 2 {
+			if msg := checkLinkSubnet(link, nodes); msg != "" {
+				errs = append(errs, fmt.Sprintf("links[%d]: %s", i, msg))
+			}
+		}
+	}
+
+	// Every interface address must be a CIDR, every addressed physical
+	// interface but the management port must have a cable plugged in, and
+	// OSPF needs a router ID once IPAM has had its chance to fill one in.
+	var configured []string
+	for _, r := range t.NetworkDevice.Routers {
+		configured = append(configured, r.Name)
+	}
+	for _, r := range t.Templates.Routers {
+		configured = append(configured, r.Name)
+	}
+	checked := make(map[string]bool)
+	for _, name := range configured {
+		n, ok := nodes[name]
+		if !ok || checked[name] {
+			continue
+		}
+		checked[name] = true
+		for j, c := range n.Config {
+			if c != nil && c.OSPF != nil && c.OSPF.RouterID == "" {
+				errs = append(errs, fmt.Sprintf("%s.config[%d].ospf.router_id: required unless a loopback is configured or ipam.loopback is set", n.Path, j))
+			}
+			if c == nil || c.Interface == "" || c.IPAddress == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(c.IPAddress); err != nil {
+				errs = append(errs, fmt.Sprintf("%s.config[%d].ip_address: %s %s: %q is not a valid CIDR address",
+					n.Path, j, name, c.Interface, c.IPAddress))
+			}
+			if topology.IsVirtualInterface(c.Interface) || topology.IsManagementInterface(c.Interface) {
+				continue
+			}
+			p := fmt.Sprintf("%s.config[%d].interface", n.Path, j)
+			adapter, port, err := topology.InterfacePort(n.Platform, c.Interface)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", p, err))
+				continue
+			}
+			if _, cabled := usedBy[portKey{name, adapter, port}]; !cabled {
+				errs = append(errs, fmt.Sprintf("%s: %s %s (adapter %d port %d) has an address but no link",
+					p, name, c.Interface, adapter, port))
+			}
+		}
+	}
+
+	return errs
+}
+
+// checkLinkSubnet verifies that when both ends of a link have an address
+// configured on the cabled interface, the two addresses share a subnet.
+// Addresses that are not CIDRs are reported with the router's config.
+func checkLinkSubnet(link Link, nodes map[string]topoNode) string {
+	type side struct {
+		name, iface string
+		net         *net.IPNet
+		raw         string
+	}
+	var sides []side
+	for _, ep := range link.Endpoints {
+		n, ok := nodes[ep.Name]
+		if !ok || len(n.Config) == 0 {
+			return ""
+		}
+		iface, addr := topology.InterfaceAddress(n.Config, n.Platform, ep.Adapter, ep.Port)
+		if addr == "" {
+			return ""
+		}
+		_, ipnet, err := net.ParseCIDR(addr)
+		if err != nil {
+			return ""
+		}
+		sides = append(sides, side{ep.Name, iface, ipnet, addr})
+	}
+	a, b := sides[0], sides[1]
+	if a.net.String() != b.net.String() {
+		return fmt.Sprintf("%s %s (%s) and %s %s (%s) are not in the same subnet",
+			a.name, a.iface, a.raw, b.name, b.iface, b.raw)
+	}
+	return ""
+}
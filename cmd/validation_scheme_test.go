@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"netdevops-cli-tool/internal/topology"
+)
+
+func TestValidateTopologyInterfaces(t *testing.T) {
+	const head = `project:
+  name: lab
+  terraform_version: "2.5.3"
+network-device:
+  routers:
+    - name: R1
+      vendor: arista
+      image: veos.qcow2
+      config:
+        - {interface: Management1, ip_address: 192.168.0.11/24}
+        - {interface: Ethernet1, ip_address: 10.0.0.0/31}
+    - name: R2
+      vendor: arista
+      image: veos.qcow2
+      config:
+        - {interface: Ethernet1, ip_address: 10.0.0.1/31}
+`
+	tests := []struct {
+		name  string
+		links string
+		want  string // substring of the error; empty for a valid topology
+	}{
+		{
+			name: "management port needs no cable",
+			links: `
+  - endpoints:
+      - {name: R1, interface: Ethernet1}
+      - {name: R2, interface: Ethernet1}`,
+		},
+		{
+			name: "adapter and port agreeing with the interface",
+			links: `
+  - endpoints:
+      - {name: R1, interface: Ethernet1, adapter: 1, port: 0}
+      - {name: R2, adapter: 1, port: 0}`,
+		},
+		{
+			name: "adapter disagreeing with the interface",
+			links: `
+  - endpoints:
+      - {name: R1, interface: Ethernet1, adapter: 2}
+      - {name: R2, interface: Ethernet1}`,
+			want: "links[0].endpoints[0].interface: R1 Ethernet1 is adapter 1 port 0, but adapter 2 port 0 is given",
+		},
+		{
+			name: "port disagreeing with the interface",
+			links: `
+  - endpoints:
+      - {name: R1, interface: Ethernet1}
+      - {name: R2, interface: Ethernet1, port: 1}`,
+			want: "links[0].endpoints[1].interface: R2 Ethernet1 is adapter 1 port 0, but adapter 1 port 1 is given",
+		},
+		{
+			name: "addressed data port without a cable",
+			links: `
+  - endpoints:
+      - {name: R1, interface: Ethernet2}
+      - {name: R2, interface: Ethernet2}`,
+			want: "R1 Ethernet1 (adapter 1 port 0) has an address but no link",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo, err := topology.Parse([]byte(head + "links:" + tt.links + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = validateTopology(&topo)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTopologyDrift(t *testing.T) {
+	tests := []struct {
+		drift string
+		want  []string
+	}{
+		{drift: "{ram: update, hda_disk_image: warn, idlepc: recreate}"},
+		{drift: "{ram: Recreate}", want: []string{`project.drift.ram: "Recreate" is not a drift policy`}},
+		{drift: "{ram: updte, cpus: warn}", want: []string{`project.drift.ram: "updte" is not a drift policy`}},
+		{drift: "{memory: update}", want: []string{`project.drift.memory: "memory" is not a node property`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.drift, func(t *testing.T) {
+			topo, err := topology.Parse([]byte(`project:
+  name: lab
+  terraform_version: "2.5.3"
+  drift: ` + tt.drift + `
+network-device:
+  routers:
+    - {name: R1, vendor: arista, image: veos.qcow2}
+links: []
+`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = validateTopology(&topo)
+			if len(tt.want) == 0 && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			for _, want := range tt.want {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
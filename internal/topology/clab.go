@@ -0,0 +1,407 @@
+package topology
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clabFile is the part of a containerlab .clab.yml file the importer and
+// exporter translate.
+type clabFile struct {
+	Name     string `yaml:"name"`
+	Topology struct {
+		Defaults clabNode            `yaml:"defaults,omitempty"`
+		Kinds    map[string]clabNode `yaml:"kinds,omitempty"`
+		Nodes    yaml.Node           `yaml:"nodes"` // decoded in file order
+		Links    []clabLink          `yaml:"links,omitempty"`
+	} `yaml:"topology"`
+}
+
+type clabNode struct {
+	Kind   string            `yaml:"kind,omitempty"`
+	Image  string            `yaml:"image,omitempty"`
+	Cmd    string            `yaml:"cmd,omitempty"`
+	Env    map[string]string `yaml:"env,omitempty"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+}
+
+type clabLink struct {
+	Endpoints []string `yaml:"endpoints"`
+}
+
+// Labels containerlab's graph command uses for node positions.
+const (
+	clabPosX = "graph-posX"
+	clabPosY = "graph-posY"
+)
+
+// clabVendors maps the containerlab router kinds to router vendors.
+var clabVendors = map[string]string{
+	"ceos":                  PlatformArista,
+	"arista_ceos":           PlatformArista,
+	"vr-veos":               PlatformArista,
+	"arista_veos":           PlatformArista,
+	"crpd":                  PlatformJuniper,
+	"juniper_crpd":          PlatformJuniper,
+	"vr-vmx":                PlatformJuniper,
+	"juniper_vmx":           PlatformJuniper,
+	"vr-vsrx":               PlatformJuniper,
+	"juniper_vsrx":          PlatformJuniper,
+	"vr-vqfx":               PlatformJuniper,
+	"juniper_vqfx":          PlatformJuniper,
+	"juniper_vjunosrouter":  PlatformJuniper,
+	"juniper_vjunosswitch":  PlatformJuniper,
+	"juniper_vjunosevolved": PlatformJuniper,
+	"vr-csr":                PlatformCisco,
+	"cisco_csr1000v":        PlatformCisco,
+	"cisco_c8000v":          PlatformCisco,
+	"vr-xrv9k":              PlatformCisco,
+	"cisco_xrv9k":           PlatformCisco,
+	"vr-n9kv":               PlatformCisco,
+	"cisco_n9kv":            PlatformCisco,
+	"cisco_iol":             PlatformCisco,
+}
+
+// ClabKinds is the containerlab kind each router vendor is exported as, and
+// the image used when a router's own image is a VM disk.
+var ClabKinds = map[string]struct{ Kind, Image string }{
+	PlatformArista:  {"arista_ceos", "ceos:latest"},
+	PlatformJuniper: {"juniper_crpd", "crpd:latest"},
+	PlatformCisco:   {"cisco_csr1000v", "vrnetlab/vr-csr:latest"},
+}
+
+// clabDataIface matches containerlab's ethN names; eth0 is the management
+// interface, like adapter 0 in GNS3.
+var clabDataIface = regexp.MustCompile(`^eth(\d+)$`)
+
+var trailingNumber = regexp.MustCompile(`(\d+)$`)
+
+// ImportClab builds a topology from a containerlab .clab.yml file. Router
+// kinds become network-device routers, linux nodes docker nodes, bridges
+// switches and host interfaces a cloud named host. ethN interfaces map to
+// adapter N. It returns a note for every guess it made and everything it
+// skipped.
+func ImportClab(data []byte) (Topology, []string, error) {
+	var f clabFile
+	var t Topology
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return t, nil, &ParseError{Err: err}
+	}
+	if f.Topology.Nodes.Kind != yaml.MappingNode {
+		return t, nil, fmt.Errorf("not a containerlab topology: topology.nodes is missing")
+	}
+	t.Version = CurrentVersion
+	t.Project.Name = f.Name
+
+	var notes []string
+	kinds := make(map[string]string) // node name → clab kind
+	for i := 0; i+1 < len(f.Topology.Nodes.Content); i += 2 {
+		name := f.Topology.Nodes.Content[i].Value
+		var n clabNode
+		if err := f.Topology.Nodes.Content[i+1].Decode(&n); err != nil {
+			return t, nil, &ParseError{Err: err}
+		}
+		n = n.inherit(f.Topology.Kinds[n.Kind]).inherit(f.Topology.Defaults)
+		pos := n.position()
+
+		switch {
+		case clabVendors[n.Kind] != "":
+			t.NetworkDevice.Routers = append(t.NetworkDevice.Routers, NetworkDevice{
+				Name:     name,
+				Vendor:   clabVendors[n.Kind],
+				Image:    n.Image,
+				Position: pos,
+			})
+			notes = append(notes, fmt.Sprintf("%s: image %q is a container image; point it at the %s disk image GNS3 runs", name, n.Image, clabVendors[n.Kind]))
+		case n.Kind == "linux":
+			t.Docker = append(t.Docker, DockerNode{
+				Name:         name,
+				Image:        n.Image,
+				StartCommand: n.Cmd,
+				Environment:  n.Env,
+				Position:     pos,
+			})
+		case n.Kind == "bridge" || n.Kind == "ovs-bridge":
+			t.Switches = append(t.Switches, Switch{Name: name, Position: pos})
+		default:
+			notes = append(notes, fmt.Sprintf("%s: kind %q is not supported, skipped along with its links", name, n.Kind))
+			continue
+		}
+		kinds[name] = n.Kind
+	}
+
+	nextPort := make(map[string]int)
+	usedPort := make(map[string]bool)
+	adapters := make(map[string]int)
+	for i, l := range f.Topology.Links {
+		if len(l.Endpoints) != 2 {
+			notes = append(notes, fmt.Sprintf("links[%d]: only links with two endpoints are supported, skipped", i))
+			continue
+		}
+		var link Link
+		for _, ep := range l.Endpoints {
+			node, iface, ok := strings.Cut(ep, ":")
+			if !ok {
+				notes = append(notes, fmt.Sprintf("links[%d]: endpoint %q is not node:interface, skipped", i, ep))
+				break
+			}
+			kind, known := kinds[node]
+			if node == "host" && !known {
+				t.Clouds = append(t.Clouds, Cloud{Name: "host"})
+				kinds[node], kind, known = "host", "host", true
+			}
+			if !known {
+				break
+			}
+
+			e := Endpoint{Name: node}
+			switch kind {
+			case "bridge", "ovs-bridge", "host":
+				// Bridge and host interfaces are arbitrary names; keep
+				// their number as the port where it is free.
+				port := -1
+				if m := trailingNumber.FindString(iface); m != "" {
+					port, _ = strconv.Atoi(m)
+				}
+				if port < 0 || usedPort[fmt.Sprintf("%s/%d", node, port)] {
+					for port = nextPort[node]; usedPort[fmt.Sprintf("%s/%d", node, port)]; port++ {
+					}
+				}
+				usedPort[fmt.Sprintf("%s/%d", node, port)] = true
+				if port >= nextPort[node] {
+					nextPort[node] = port + 1
+				}
+				e.Port = port
+			default:
+				if m := clabDataIface.FindStringSubmatch(iface); m != nil {
+					e.Adapter, _ = strconv.Atoi(m[1])
+				} else if a, p, err := InterfacePort(clabVendors[kind], iface); err == nil && kind != "linux" {
+					e.Adapter, e.Port = a, p
+				} else {
+					notes = append(notes, fmt.Sprintf("links[%d]: %s interface %q not understood, link skipped", i, node, iface))
+					e.Name = ""
+				}
+				if e.Adapter+1 > adapters[node] {
+					adapters[node] = e.Adapter + 1
+				}
+			}
+			if e.Name == "" {
+				break
+			}
+			link.Endpoints = append(link.Endpoints, e)
+		}
+		if len(link.Endpoints) == 2 {
+			t.Links = append(t.Links, link)
+		}
+	}
+
+	// Give nodes enough adapters for the interfaces they are cabled on.
+	for i := range t.NetworkDevice.Routers {
+		r := &t.NetworkDevice.Routers[i]
+		if adapters[r.Name] > DefaultQemuResources.Adapters {
+			r.Adapters = adapters[r.Name]
+		}
+	}
+	for i := range t.Docker {
+		d := &t.Docker[i]
+		if adapters[d.Name] > 1 {
+			d.Adapters = adapters[d.Name]
+		}
+	}
+	return t, notes, nil
+}
+
+// inherit fills the unset fields of n from def (a kinds or defaults entry).
+func (n clabNode) inherit(def clabNode) clabNode {
+	if n.Kind == "" {
+		n.Kind = def.Kind
+	}
+	if n.Image == "" {
+		n.Image = def.Image
+	}
+	if n.Cmd == "" {
+		n.Cmd = def.Cmd
+	}
+	for k, v := range def.Env {
+		if n.Env == nil {
+			n.Env = make(map[string]string)
+		}
+		if _, ok := n.Env[k]; !ok {
+			n.Env[k] = v
+		}
+	}
+	return n
+}
+
+// position reads the node's graph-posX/graph-posY labels.
+func (n clabNode) position() Position {
+	var pos Position
+	x, errX := strconv.Atoi(n.Labels[clabPosX])
+	y, errY := strconv.Atoi(n.Labels[clabPosY])
+	if errX == nil && errY == nil {
+		pos.set(x, y)
+	}
+	return pos
+}
+
+// ExportClab renders a loaded topology as a containerlab .clab.yml file.
+// Routers use the kind and image of ClabKinds unless kinds or images (both
+// keyed by vendor) override them; docker nodes become linux nodes, switches
+// and hubs bridges, and cloud ports host interfaces. Adapter N is exported
+// as ethN. It returns a note for everything that has no containerlab
+// equivalent.
+func ExportClab(t Topology, kinds, images map[string]string) ([]byte, []string, error) {
+	var notes []string
+	nodes := make(map[string]clabNode)
+	platforms := t.Platforms()
+	hasConfig := false
+
+	addRouter := func(name, vendor, image string, pos Position) {
+		platform := platforms[name]
+		def := ClabKinds[platform]
+		n := clabNode{Kind: def.Kind, Image: def.Image}
+		if k := kinds[platform]; k != "" {
+			n.Kind = k
+		}
+		switch {
+		case images[platform] != "":
+			n.Image = images[platform]
+		case image != "" && !isDiskImage(image):
+			n.Image = image
+		default:
+			notes = append(notes, fmt.Sprintf("%s: no container image for %s, using %q; set one with --image %s=<image>", name, vendor, n.Image, platform))
+		}
+		n.Labels = positionLabels(pos)
+		nodes[name] = n
+	}
+	for _, r := range t.NetworkDevice.Routers {
+		addRouter(r.Name, r.Vendor, r.Image, r.Position)
+		hasConfig = hasConfig || len(r.Config) > 0
+	}
+	for _, r := range t.Templates.Routers {
+		if _, ok := nodes[r.Name]; ok {
+			continue
+		}
+		addRouter(r.Name, r.Vendor, "", r.Position)
+		hasConfig = hasConfig || len(r.Config) > 0
+	}
+	for _, d := range t.Docker {
+		nodes[d.Name] = clabNode{Kind: "linux", Image: d.Image, Cmd: d.StartCommand, Env: d.Environment, Labels: positionLabels(d.Position)}
+	}
+	for _, s := range t.Switches {
+		nodes[s.Name] = clabNode{Kind: "bridge", Labels: positionLabels(s.Position)}
+	}
+	for _, h := range t.Hubs {
+		nodes[h.Name] = clabNode{Kind: "bridge", Labels: positionLabels(h.Position)}
+	}
+	for _, s := range t.Templates.Servers {
+		notes = append(notes, fmt.Sprintf("%s: template server has no containerlab equivalent, skipped", s.Name))
+	}
+	for _, n := range t.TemplateNodes() {
+		if n.Kind != "ethernet_hub" {
+			notes = append(notes, fmt.Sprintf("%s: %s node has no containerlab equivalent, skipped", n.Name, n.Kind))
+		}
+	}
+	for _, n := range t.IOU {
+		notes = append(notes, fmt.Sprintf("%s: iou node has no containerlab equivalent, skipped", n.Name))
+	}
+	for _, n := range t.Dynamips {
+		notes = append(notes, fmt.Sprintf("%s: dynamips node has no containerlab equivalent, skipped", n.Name))
+	}
+	if hasConfig {
+		notes = append(notes, "router config blocks are not exported; containerlab nodes start unconfigured")
+	}
+	bridges := make(map[string]bool)
+	for name, n := range nodes {
+		if n.Kind == "bridge" {
+			bridges[name] = true
+		}
+	}
+	clouds := make(map[string]bool)
+	for _, c := range t.Clouds {
+		clouds[c.Name] = true
+	}
+
+	var links []clabLink
+	for _, l := range t.Links {
+		var eps []string
+		for _, e := range l.Endpoints {
+			switch {
+			case bridges[e.Name]:
+				// The bridge side is a host interface; it needs a name
+				// unique on the host.
+				eps = append(eps, fmt.Sprintf("%s:%sp%d", e.Name, e.Name, e.Port))
+			case clouds[e.Name]:
+				eps = append(eps, fmt.Sprintf("host:%sp%d", e.Name, e.Port))
+			case nodes[e.Name].Kind != "":
+				if e.Adapter == 0 && nodes[e.Name].Kind != "linux" {
+					notes = append(notes, fmt.Sprintf("%s: link on the management adapter skipped; containerlab manages eth0", LinkKey(l, platforms)))
+					eps = nil
+				} else {
+					eps = append(eps, fmt.Sprintf("%s:eth%d", e.Name, e.Adapter))
+				}
+			default:
+				eps = nil
+			}
+			if eps == nil {
+				break
+			}
+		}
+		if len(eps) == 2 {
+			links = append(links, clabLink{Endpoints: eps})
+		}
+	}
+
+	// Emit the nodes in name order so exports diff cleanly.
+	var names []string
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var f clabFile
+	f.Name = t.Project.Name
+	f.Topology.Nodes = yaml.Node{Kind: yaml.MappingNode}
+	for _, name := range names {
+		var v yaml.Node
+		if err := v.Encode(nodes[name]); err != nil {
+			return nil, nil, err
+		}
+		f.Topology.Nodes.Content = append(f.Topology.Nodes.Content, scalar(name), &v)
+	}
+	f.Topology.Links = links
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), notes, nil
+}
+
+func positionLabels(p Position) map[string]string {
+	if !p.Placed() {
+		return nil
+	}
+	x, y := p.XY()
+	return map[string]string{clabPosX: strconv.Itoa(x), clabPosY: strconv.Itoa(y)}
+}
+
+func isDiskImage(image string) bool {
+	l := strings.ToLower(image)
+	for _, ext := range []string{".qcow2", ".vmdk", ".img", ".iso", ".bin"} {
+		if strings.HasSuffix(l, ext) {
+			return true
+		}
+	}
+	return false
+}
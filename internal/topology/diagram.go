@@ -0,0 +1,368 @@
+package topology
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"net"
+	"strings"
+)
+
+// Diagram is the drawable view of a topology: nodes grouped by type, with
+// their canvas positions, and links labelled with interfaces and subnets.
+type Diagram struct {
+	Name   string
+	Groups []DiagramGroup
+	Edges  []DiagramEdge
+}
+
+// DiagramGroup is one node type, e.g. Routers or Switches.
+type DiagramGroup struct {
+	Name  string
+	Nodes []DiagramNode
+}
+
+type DiagramNode struct {
+	ID    string // safe identifier for every output format
+	Name  string
+	Label string // name plus a detail line such as the vendor or image
+	X, Y  int
+}
+
+// DiagramEdge is a link; Subnet is the network of the router addresses on
+// it, empty when neither end is addressed.
+type DiagramEdge struct {
+	From, To         string // node IDs
+	FromPort, ToPort string // interface name and adapter/port
+	FromAddr, ToAddr string
+	Subnet           string
+}
+
+// Diagram builds the diagram of a loaded topology. Nodes keep the positions
+// the layout gave them.
+func (t Topology) Diagram() Diagram {
+	d := Diagram{Name: t.Project.Name}
+	ids := make(map[string]string)
+	group := func(name string) *DiagramGroup {
+		for i := range d.Groups {
+			if d.Groups[i].Name == name {
+				return &d.Groups[i]
+			}
+		}
+		d.Groups = append(d.Groups, DiagramGroup{Name: name})
+		return &d.Groups[len(d.Groups)-1]
+	}
+	add := func(groupName, name, detail string, pos Position) {
+		if name == "" || ids[name] != "" {
+			return
+		}
+		ids[name] = fmt.Sprintf("n%d", len(ids))
+		label := name
+		if detail != "" {
+			label += "\n" + detail
+		}
+		x, y := pos.XY()
+		g := group(groupName)
+		g.Nodes = append(g.Nodes, DiagramNode{ID: ids[name], Name: name, Label: label, X: x, Y: y})
+	}
+
+	for _, r := range t.NetworkDevice.Routers {
+		add("Routers", r.Name, r.Vendor, r.Position)
+	}
+	for _, r := range t.Templates.Routers {
+		add("Routers", r.Name, r.Vendor, r.Position)
+	}
+	for _, s := range t.Templates.Servers {
+		add("Servers", s.Name, s.TemplateName, s.Position)
+	}
+	for _, s := range t.Switches {
+		add("Switches", s.Name, "", s.Position)
+	}
+	for _, c := range t.Clouds {
+		add("Clouds", c.Name, "", c.Position)
+	}
+	for _, dn := range t.Docker {
+		add("Docker", dn.Name, dn.Image, dn.Position)
+	}
+	for _, n := range t.VPCS {
+		add("VPCS", n.Name, "", n.Position)
+	}
+	for _, n := range t.NAT {
+		add("NAT", n.Name, "", n.Position)
+	}
+	for _, n := range t.Hubs {
+		add("Hubs", n.Name, "", n.Position)
+	}
+	builtFrom := func(image, template string) string {
+		if image != "" {
+			return image
+		}
+		return template
+	}
+	for _, n := range t.IOU {
+		add("IOU", n.Name, builtFrom(n.Image, n.TemplateName), n.Position)
+	}
+	for _, n := range t.Dynamips {
+		add("Dynamips", n.Name, builtFrom(n.Image, n.TemplateName), n.Position)
+	}
+
+	platforms := t.Platforms()
+	configs := make(map[string]ConfigList)
+	for _, r := range t.ConfiguredRouters() {
+		configs[r.Name] = r.Config
+	}
+	end := func(e Endpoint) (string, string) {
+		iface, addr := InterfaceAddress(configs[e.Name], platforms[e.Name], e.Adapter, e.Port)
+		return fmt.Sprintf("%s (%d/%d)", iface, e.Adapter, e.Port), addr
+	}
+	for _, l := range t.Links {
+		if len(l.Endpoints) != 2 || ids[l.Endpoints[0].Name] == "" || ids[l.Endpoints[1].Name] == "" {
+			continue
+		}
+		a, b := l.Endpoints[0], l.Endpoints[1]
+		e := DiagramEdge{From: ids[a.Name], To: ids[b.Name]}
+		e.FromPort, e.FromAddr = end(a)
+		e.ToPort, e.ToAddr = end(b)
+		e.Subnet = subnetOf(e.FromAddr, e.ToAddr)
+		d.Edges = append(d.Edges, e)
+	}
+	return d
+}
+
+// subnetOf returns the network of the addresses on a link, listing both
+// when they disagree.
+func subnetOf(addrs ...string) string {
+	var nets []string
+	for _, a := range addrs {
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			continue
+		}
+		if len(nets) == 0 || nets[0] != n.String() {
+			nets = append(nets, n.String())
+		}
+	}
+	return strings.Join(nets, " / ")
+}
+
+// edgeLabel is the one-line label of a link for formats without per-end
+// labels.
+func (e DiagramEdge) edgeLabel() string {
+	label := e.FromPort + " ↔ " + e.ToPort
+	if e.Subnet != "" {
+		label += "\n" + e.Subnet
+	}
+	return label
+}
+
+// Shapes and colours of each group, shared by every renderer.
+var diagramStyles = map[string]struct{ Shape, Fill string }{
+	"Routers":  {"ellipse", "#dae8fc"},
+	"Servers":  {"box", "#e1d5e7"},
+	"Switches": {"box", "#d5e8d4"},
+	"Clouds":   {"ellipse", "#f5f5f5"},
+	"Docker":   {"box", "#fff2cc"},
+	"VPCS":     {"box", "#fff2cc"},
+	"NAT":      {"ellipse", "#f5f5f5"},
+	"Hubs":     {"box", "#d5e8d4"},
+	"IOU":      {"ellipse", "#dae8fc"},
+	"Dynamips": {"ellipse", "#dae8fc"},
+}
+
+// DOT renders the diagram as a Graphviz graph with one cluster per group.
+func (d Diagram) DOT() []byte {
+	var b bytes.Buffer
+	q := func(s string) string {
+		return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `"`, `\"`), "\n", `\n`) + `"`
+	}
+	fmt.Fprintf(&b, "graph %s {\n", q(d.Name))
+	b.WriteString("  graph [fontname=\"Helvetica\", overlap=false, splines=true];\n")
+	b.WriteString("  node [fontname=\"Helvetica\", style=filled];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+	for i, g := range d.Groups {
+		st := diagramStyles[g.Name]
+		fmt.Fprintf(&b, "\n  subgraph cluster_%d {\n    label=%s;\n", i, q(g.Name))
+		for _, n := range g.Nodes {
+			fmt.Fprintf(&b, "    %s [label=%s, shape=%s, fillcolor=%s];\n", n.ID, q(n.Label), st.Shape, q(st.Fill))
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("\n")
+	for _, e := range d.Edges {
+		fmt.Fprintf(&b, "  %s -- %s [taillabel=%s, headlabel=%s", e.From, e.To, q(e.FromPort), q(e.ToPort))
+		if e.Subnet != "" {
+			fmt.Fprintf(&b, ", label=%s", q(e.Subnet))
+		}
+		b.WriteString("];\n")
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// Mermaid renders the diagram as a Mermaid flowchart with one subgraph per
+// group.
+func (d Diagram) Mermaid() []byte {
+	var b bytes.Buffer
+	q := func(s string) string {
+		s = strings.ReplaceAll(s, `"`, "#quot;")
+		return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+	}
+	b.WriteString("flowchart LR\n")
+	for i, g := range d.Groups {
+		fmt.Fprintf(&b, "  subgraph g%d[%s]\n", i, q(g.Name))
+		for _, n := range g.Nodes {
+			if diagramStyles[g.Name].Shape == "ellipse" {
+				fmt.Fprintf(&b, "    %s([%s])\n", n.ID, q(n.Label))
+			} else {
+				fmt.Fprintf(&b, "    %s[%s]\n", n.ID, q(n.Label))
+			}
+		}
+		b.WriteString("  end\n")
+	}
+	for _, e := range d.Edges {
+		fmt.Fprintf(&b, "  %s ---|%s| %s\n", e.From, q(e.edgeLabel()), e.To)
+	}
+	return b.Bytes()
+}
+
+// Node size and margin of the positioned formats (draw.io and SVG).
+const (
+	diagramNodeW  = 120
+	diagramNodeH  = 44
+	diagramMargin = 80
+)
+
+// positioned returns every node with its position shifted so the drawing
+// starts at the margin.
+func (d Diagram) positioned() (map[string]DiagramNode, int, int) {
+	nodes := make(map[string]DiagramNode)
+	minX, minY, maxX, maxY := 0, 0, 0, 0
+	first := true
+	for _, g := range d.Groups {
+		for _, n := range g.Nodes {
+			if first || n.X < minX {
+				minX = n.X
+			}
+			if first || n.Y < minY {
+				minY = n.Y
+			}
+			if first || n.X > maxX {
+				maxX = n.X
+			}
+			if first || n.Y > maxY {
+				maxY = n.Y
+			}
+			first = false
+		}
+	}
+	for _, g := range d.Groups {
+		for _, n := range g.Nodes {
+			n.X += diagramMargin - minX
+			n.Y += diagramMargin - minY
+			nodes[n.ID] = n
+		}
+	}
+	return nodes, maxX - minX + diagramNodeW + 2*diagramMargin, maxY - minY + diagramNodeH + 2*diagramMargin
+}
+
+// DrawIO renders the diagram as a draw.io (diagrams.net) file. Nodes keep
+// their canvas positions and are coloured by group.
+func (d Diagram) DrawIO() []byte {
+	var b bytes.Buffer
+	esc := func(s string) string { return strings.ReplaceAll(html.EscapeString(s), "\n", "&#xa;") }
+	nodes, _, _ := d.positioned()
+	fmt.Fprintf(&b, "<mxfile host=\"netdevops\">\n  <diagram name=%q>\n    <mxGraphModel>\n      <root>\n", esc(d.Name))
+	b.WriteString("        <mxCell id=\"0\"/>\n        <mxCell id=\"1\" parent=\"0\"/>\n")
+	for _, g := range d.Groups {
+		st := diagramStyles[g.Name]
+		shape := "rounded=1;"
+		if st.Shape == "ellipse" {
+			shape = "ellipse;"
+		}
+		for _, n := range g.Nodes {
+			n = nodes[n.ID]
+			fmt.Fprintf(&b, "        <mxCell id=%q value=\"%s\" style=\"%swhiteSpace=wrap;html=0;fillColor=%s;\" vertex=\"1\" parent=\"1\">\n", n.ID, esc(n.Label), shape, st.Fill)
+			fmt.Fprintf(&b, "          <mxGeometry x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" as=\"geometry\"/>\n        </mxCell>\n", n.X, n.Y, diagramNodeW, diagramNodeH)
+		}
+	}
+	for i, e := range d.Edges {
+		fmt.Fprintf(&b, "        <mxCell id=\"e%d\" value=\"%s\" style=\"endArrow=none;html=0;fontSize=9;\" edge=\"1\" parent=\"1\" source=%q target=%q>\n", i, esc(e.Subnet), e.From, e.To)
+		b.WriteString("          <mxGeometry relative=\"1\" as=\"geometry\"/>\n        </mxCell>\n")
+		// Interface labels sit at either end of the edge.
+		for j, end := range []struct {
+			label string
+			x     string
+		}{{e.FromPort, "-0.7"}, {e.ToPort, "0.7"}} {
+			fmt.Fprintf(&b, "        <mxCell id=\"e%dl%d\" value=\"%s\" style=\"edgeLabel;html=0;fontSize=8;\" vertex=\"1\" connectable=\"0\" parent=\"e%d\">\n", i, j, esc(end.label), i)
+			fmt.Fprintf(&b, "          <mxGeometry x=\"%s\" relative=\"1\" as=\"geometry\"><mxPoint as=\"offset\"/></mxGeometry>\n        </mxCell>\n", end.x)
+		}
+	}
+	b.WriteString("      </root>\n    </mxGraphModel>\n  </diagram>\n</mxfile>\n")
+	return b.Bytes()
+}
+
+// SVG renders the diagram as a standalone SVG image with a legend of the
+// groups. It needs no Graphviz.
+func (d Diagram) SVG() []byte {
+	var b bytes.Buffer
+	nodes, w, h := d.positioned()
+	legendH := 20*len(d.Groups) + 20
+	fmt.Fprintf(&b, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"Helvetica, Arial, sans-serif\">\n", w+160, max(h, legendH), w+160, max(h, legendH))
+	fmt.Fprintf(&b, "  <title>%s</title>\n  <rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n", html.EscapeString(d.Name))
+
+	b.WriteString("  <g stroke=\"#555555\" stroke-width=\"1.5\">\n")
+	for _, e := range d.Edges {
+		x1, y1 := nodes[e.From].X+diagramNodeW/2, nodes[e.From].Y+diagramNodeH/2
+		x2, y2 := nodes[e.To].X+diagramNodeW/2, nodes[e.To].Y+diagramNodeH/2
+		fmt.Fprintf(&b, "    <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\"/>\n", x1, y1, x2, y2)
+	}
+	b.WriteString("  </g>\n  <g font-size=\"9\" fill=\"#333333\" text-anchor=\"middle\">\n")
+	for _, e := range d.Edges {
+		x1, y1 := float64(nodes[e.From].X+diagramNodeW/2), float64(nodes[e.From].Y+diagramNodeH/2)
+		x2, y2 := float64(nodes[e.To].X+diagramNodeW/2), float64(nodes[e.To].Y+diagramNodeH/2)
+		at := func(f float64) (float64, float64) { return x1 + (x2-x1)*f, y1 + (y2-y1)*f }
+		x, y := at(0.28)
+		fmt.Fprintf(&b, "    <text x=\"%.0f\" y=\"%.0f\">%s</text>\n", x, y, html.EscapeString(e.FromPort))
+		x, y = at(0.72)
+		fmt.Fprintf(&b, "    <text x=\"%.0f\" y=\"%.0f\">%s</text>\n", x, y, html.EscapeString(e.ToPort))
+		if e.Subnet != "" {
+			x, y = at(0.5)
+			fmt.Fprintf(&b, "    <text x=\"%.0f\" y=\"%.0f\" font-weight=\"bold\">%s</text>\n", x, y-4, html.EscapeString(e.Subnet))
+		}
+	}
+	b.WriteString("  </g>\n")
+
+	for _, g := range d.Groups {
+		for _, n := range g.Nodes {
+			n = nodes[n.ID]
+			fmt.Fprintf(&b, "  <g>\n    %s\n", svgShape(diagramStyles[g.Name].Shape, diagramStyles[g.Name].Fill, n.X, n.Y))
+			lines := strings.Split(n.Label, "\n")
+			for i, line := range lines {
+				size, weight := 12, "bold"
+				if i > 0 {
+					size, weight = 9, "normal"
+				}
+				y := n.Y + diagramNodeH/2 + 4 + (i*14 - (len(lines)-1)*7)
+				fmt.Fprintf(&b, "    <text x=\"%d\" y=\"%d\" font-size=\"%d\" font-weight=\"%s\" text-anchor=\"middle\">%s</text>\n", n.X+diagramNodeW/2, y, size, weight, html.EscapeString(line))
+			}
+			b.WriteString("  </g>\n")
+		}
+	}
+
+	// Legend
+	fmt.Fprintf(&b, "  <g font-size=\"11\">\n")
+	for i, g := range d.Groups {
+		y := 20 + i*20
+		fmt.Fprintf(&b, "    <rect x=\"%d\" y=\"%d\" width=\"14\" height=\"14\" fill=\"%s\" stroke=\"#555555\"/>\n", w+10, y, diagramStyles[g.Name].Fill)
+		fmt.Fprintf(&b, "    <text x=\"%d\" y=\"%d\">%s</text>\n", w+30, y+11, html.EscapeString(g.Name))
+	}
+	b.WriteString("  </g>\n</svg>\n")
+	return b.Bytes()
+}
+
+func svgShape(shape, fill string, x, y int) string {
+	if shape == "ellipse" {
+		return fmt.Sprintf("<ellipse cx=\"%d\" cy=\"%d\" rx=\"%d\" ry=\"%d\" fill=\"%s\" stroke=\"#555555\"/>", x+diagramNodeW/2, y+diagramNodeH/2, diagramNodeW/2, diagramNodeH/2, fill)
+	}
+	return fmt.Sprintf("<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" rx=\"6\" fill=\"%s\" stroke=\"#555555\"/>", x, y, diagramNodeW, diagramNodeH, fill)
+}
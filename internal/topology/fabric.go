@@ -0,0 +1,119 @@
+package topology
+
+import "fmt"
+
+// Fabric describes a spine-leaf fabric that the loader expands into routers
+// and links, so large labs do not have to be written out by hand.
+type Fabric struct {
+	Spines       FabricRole `yaml:"spines"`
+	Leaves       FabricRole `yaml:"leaves"`
+	BorderLeaves FabricRole `yaml:"border_leaves,omitempty"`
+	// Uplinks is the number of links from every leaf to the spines, spread
+	// round-robin. It defaults to one per spine.
+	Uplinks int `yaml:"uplinks,omitempty"`
+}
+
+// FabricRole is one tier of the fabric. Nodes are named Prefix1..PrefixN
+// and built from Image (a QEMU router) or TemplateName (a GNS3 template).
+type FabricRole struct {
+	Count        int    `yaml:"count"`
+	Prefix       string `yaml:"prefix,omitempty"`
+	Vendor       string `yaml:"vendor,omitempty"`
+	Image        string `yaml:"image,omitempty"`
+	TemplateName string `yaml:"template_name,omitempty"`
+
+	QemuResources `yaml:",inline"`
+}
+
+// expandFabric appends the fabric's routers and links to the topology and
+// drops the fabric block, leaving a topology the rest of the pipeline reads
+// like any hand-written one. Spines and leaves keep adapter 0 for
+// management: leaf uplinks start at adapter 1 and spine downlinks use
+// adapters 1, 2, ... in leaf order, border leaves last.
+func (t *Topology) expandFabric() {
+	f := t.Fabric
+	if f == nil {
+		return
+	}
+	t.Fabric = nil
+
+	spines := t.addFabricRole(f.Spines, "spine")
+	leaves := t.addFabricRole(f.Leaves, "leaf")
+	leaves = append(leaves, t.addFabricRole(f.BorderLeaves, "border")...)
+	t.tiers = make(map[string]int)
+	for _, s := range spines {
+		t.tiers[s] = 0
+	}
+	for _, l := range leaves {
+		t.tiers[l] = 1
+	}
+	if len(spines) == 0 {
+		return
+	}
+
+	uplinks := f.Uplinks
+	if uplinks == 0 {
+		uplinks = len(spines)
+	}
+	// spineAdapter is the next free downlink adapter of each spine.
+	spineAdapter := make([]int, len(spines))
+	for i := range spineAdapter {
+		spineAdapter[i] = 1
+	}
+	for _, leaf := range leaves {
+		for u := 0; u < uplinks; u++ {
+			s := u % len(spines)
+			t.Links = append(t.Links, Link{Endpoints: []Endpoint{
+				{Name: leaf, Adapter: 1 + u},
+				{Name: spines[s], Adapter: spineAdapter[s]},
+			}})
+			spineAdapter[s]++
+		}
+	}
+
+	// Give QEMU routers enough adapters for their cabling unless the
+	// fabric says otherwise.
+	need := make(map[string]int)
+	for i, s := range spines {
+		need[s] = spineAdapter[i]
+	}
+	for _, l := range leaves {
+		need[l] = 1 + uplinks
+	}
+	for i := range t.NetworkDevice.Routers {
+		r := &t.NetworkDevice.Routers[i]
+		if n, ok := need[r.Name]; ok && r.Adapters == 0 && n > t.Project.Defaults.Adapters {
+			r.Adapters = n
+		}
+	}
+}
+
+// addFabricRole declares count routers for a fabric role and returns their
+// names.
+func (t *Topology) addFabricRole(role FabricRole, defaultPrefix string) []string {
+	prefix := role.Prefix
+	if prefix == "" {
+		prefix = defaultPrefix
+	}
+	var names []string
+	for i := 1; i <= role.Count; i++ {
+		name := fmt.Sprintf("%s%d", prefix, i)
+		names = append(names, name)
+		if role.TemplateName != "" && role.Image == "" {
+			t.Templates.Routers = append(t.Templates.Routers, Router{
+				Name:         name,
+				Vendor:       role.Vendor,
+				TemplateName: role.TemplateName,
+				Start:        true,
+			})
+			continue
+		}
+		t.NetworkDevice.Routers = append(t.NetworkDevice.Routers, NetworkDevice{
+			Name:          name,
+			Vendor:        role.Vendor,
+			Image:         role.Image,
+			QemuResources: role.QemuResources,
+		})
+	}
+	return names
+}
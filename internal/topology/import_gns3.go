@@ -0,0 +1,223 @@
+package topology
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// gns3Project is the part of a .gns3 project file the importer reads.
+type gns3Project struct {
+	Name     string `json:"name"`
+	Topology struct {
+		Nodes []struct {
+			NodeID      string                 `json:"node_id"`
+			Name        string                 `json:"name"`
+			NodeType    string                 `json:"node_type"`
+			ConsoleType string                 `json:"console_type"`
+			Symbol      string                 `json:"symbol"`
+			X           int                    `json:"x"`
+			Y           int                    `json:"y"`
+			Properties  map[string]interface{} `json:"properties"`
+		} `json:"nodes"`
+		Links []struct {
+			Nodes []struct {
+				NodeID        string `json:"node_id"`
+				AdapterNumber int    `json:"adapter_number"`
+				PortNumber    int    `json:"port_number"`
+			} `json:"nodes"`
+		} `json:"links"`
+	} `json:"topology"`
+}
+
+var invalidNodeChars = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// ImportGNS3 builds a topology from the JSON of a .gns3 project file. QEMU
+// nodes become network-device routers, Ethernet switches and clouds map to
+// their sections, Docker, VPCS, NAT, hub, IOU and Dynamips nodes to theirs.
+// It returns a note for every guess it made and every node it skipped.
+func ImportGNS3(data []byte) (Topology, []string, error) {
+	var p gns3Project
+	var t Topology
+	if err := json.Unmarshal(data, &p); err != nil {
+		return t, nil, fmt.Errorf("not a GNS3 project file: %w", err)
+	}
+	t.Version = CurrentVersion
+	t.Project.Name = p.Name
+
+	var notes []string
+	names := make(map[string]string) // node_id → topology name
+	used := make(map[string]bool)
+	for _, n := range p.Topology.Nodes {
+		name := invalidNodeChars.ReplaceAllString(n.Name, "-")
+		name = strings.TrimLeft(name, ".-")
+		if name == "" {
+			name = "node"
+		}
+		for base, i := name, 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		if name != n.Name {
+			notes = append(notes, fmt.Sprintf("node %q renamed to %q (names may only contain letters, digits, _ . -)", n.Name, name))
+		}
+
+		pos := Position{}
+		pos.set(n.X, n.Y)
+		props := gns3Props(n.Properties)
+
+		switch n.NodeType {
+		case "qemu":
+			image := props.str("hda_disk_image")
+			vendor, ok := guessVendor(n.Name, image, n.Symbol)
+			if !ok {
+				notes = append(notes, fmt.Sprintf("%s: vendor not recognised from %q, assumed %s", name, image, vendor))
+			}
+			t.NetworkDevice.Routers = append(t.NetworkDevice.Routers, NetworkDevice{
+				Name:       name,
+				Vendor:     vendor,
+				MacAddress: props.str("mac_address"),
+				Image:      image,
+				QemuResources: QemuResources{
+					RAM:         props.int("ram"),
+					CPUs:        props.int("cpus"),
+					Adapters:    props.int("adapters"),
+					AdapterType: props.str("adapter_type"),
+					Platform:    props.str("platform"),
+					ConsoleType: n.ConsoleType,
+					Options:     props.str("options"),
+				},
+				Position: pos,
+			})
+		case "ethernet_switch":
+			t.Switches = append(t.Switches, Switch{Name: name, Position: pos})
+		case "cloud":
+			t.Clouds = append(t.Clouds, Cloud{Name: name, Position: pos})
+		case "docker":
+			d := DockerNode{
+				Name:         name,
+				Image:        props.str("image"),
+				Adapters:     props.int("adapters"),
+				StartCommand: props.str("start_command"),
+				ConsoleType:  n.ConsoleType,
+				Position:     pos,
+			}
+			for _, line := range strings.Split(props.str("environment"), "\n") {
+				if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+					if d.Environment == nil {
+						d.Environment = make(map[string]string)
+					}
+					d.Environment[k] = v
+				}
+			}
+			t.Docker = append(t.Docker, d)
+		case "vpcs":
+			t.VPCS = append(t.VPCS, TemplateNode{Name: name, Position: pos})
+		case "nat":
+			t.NAT = append(t.NAT, TemplateNode{Name: name, Position: pos})
+		case "ethernet_hub":
+			t.Hubs = append(t.Hubs, TemplateNode{Name: name, Position: pos})
+		case "iou":
+			t.IOU = append(t.IOU, IOUNode{
+				Name:             name,
+				Image:            props.str("path"),
+				RAM:              props.int("ram"),
+				NVRAM:            props.int("nvram"),
+				EthernetAdapters: props.int("ethernet_adapters"),
+				SerialAdapters:   props.int("serial_adapters"),
+				Position:         pos,
+			})
+		case "dynamips":
+			d := DynamipsNode{
+				Name:     name,
+				Platform: props.str("platform"),
+				Image:    props.str("image"),
+				RAM:      props.int("ram"),
+				NVRAM:    props.int("nvram"),
+				IdlePC:   props.str("idlepc"),
+				Position: pos,
+			}
+			d.Slots = props.list("slot", 7)
+			d.WICs = props.list("wic", 3)
+			t.Dynamips = append(t.Dynamips, d)
+		default:
+			notes = append(notes, fmt.Sprintf("%s: node type %q is not supported, skipped along with its links", n.Name, n.NodeType))
+			continue
+		}
+		names[n.NodeID] = name
+		used[name] = true
+	}
+
+	for i, l := range p.Topology.Links {
+		if len(l.Nodes) != 2 {
+			notes = append(notes, fmt.Sprintf("link %d: %d endpoints, skipped", i, len(l.Nodes)))
+			continue
+		}
+		var link Link
+		for _, ep := range l.Nodes {
+			name, ok := names[ep.NodeID]
+			if !ok {
+				break
+			}
+			link.Endpoints = append(link.Endpoints, Endpoint{Name: name, Adapter: ep.AdapterNumber, Port: ep.PortNumber})
+		}
+		if len(link.Endpoints) == 2 {
+			t.Links = append(t.Links, link)
+		}
+	}
+	return t, notes, nil
+}
+
+// gns3Props reads typed values out of a node's properties.
+type gns3Props map[string]interface{}
+
+func (p gns3Props) str(key string) string {
+	if s, ok := p[key].(string); ok {
+		return s
+	}
+	return ""
+}
+
+func (p gns3Props) int(key string) int {
+	if f, ok := p[key].(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+// list collects the string properties prefix0 … prefix<n-1>, e.g. the
+// slots of a Dynamips router, dropping trailing empty ones.
+func (p gns3Props) list(prefix string, n int) []string {
+	var out []string
+	for i := 0; i < n; i++ {
+		out = append(out, p.str(fmt.Sprintf("%s%d", prefix, i)))
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// guessVendor picks the router vendor from whatever names the node
+// carries. It reports false when nothing matched and arista was assumed.
+func guessVendor(fields ...string) (string, bool) {
+	s := strings.ToLower(strings.Join(fields, " "))
+	has := func(subs ...string) bool {
+		for _, sub := range subs {
+			if strings.Contains(s, sub) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("arista", "veos", "ceos", "eos"):
+		return PlatformArista, true
+	case has("juniper", "junos", "vmx", "vsrx", "vqfx"):
+		return PlatformJuniper, true
+	case has("cisco", "vios", "iosv", "csr", "nxos", "nx-os", "xrv", "c7200"):
+		return PlatformCisco, true
+	default:
+		return PlatformArista, false
+	}
+}
@@ -0,0 +1,260 @@
+package topology
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Platforms used to translate interface names. Routers use their vendor;
+// every other node type has a fixed platform.
+const (
+	PlatformArista  = "arista"
+	PlatformCisco   = "cisco"
+	PlatformJuniper = "juniper"
+	PlatformSwitch  = "switch" // ethernet switches and hubs: one adapter, many ports
+	PlatformLinux   = "linux"  // docker hosts
+	PlatformVPCS    = "vpcs"
+	PlatformIOU     = "iou" // IOU and Dynamips: slot/port maps to adapter/port
+	PlatformCloud   = "cloud"
+)
+
+// ifaceRule matches one interface naming scheme. The regexp captures the
+// interface numbers, which at() turns into a GNS3 adapter/port pair.
+type ifaceRule struct {
+	re *regexp.Regexp
+	at func(n []int) (adapter, port int)
+}
+
+// interfaceMap translates the interface names of one platform to GNS3
+// adapter/port numbers and back.
+type interfaceMap struct {
+	rules []ifaceRule
+	name  func(adapter, port int) string
+}
+
+func rule(pattern string, at func(n []int) (int, int)) ifaceRule {
+	return ifaceRule{re: regexp.MustCompile(`(?i)^` + pattern + `$`), at: at}
+}
+
+// interfaceMaps is the vendor-aware mapping table. GNS3 numbers adapters
+// from 0; each platform reserves adapter 0 for its management port.
+var interfaceMaps = map[string]interfaceMap{
+	// vEOS: Management1 is adapter 0, EthernetN is adapter N.
+	PlatformArista: {
+		rules: []ifaceRule{
+			rule(`(?:management|ma)(\d+)`, func(n []int) (int, int) { return 0, 0 }),
+			rule(`(?:ethernet|eth|et)(\d+)`, func(n []int) (int, int) { return n[0], 0 }),
+		},
+		name: func(a, p int) string {
+			if a == 0 {
+				return "Management1"
+			}
+			return fmt.Sprintf("Ethernet%d", a)
+		},
+	},
+	// IOSv: GigabitEthernet0/N is adapter N. CSR1000v: GigabitEthernetN
+	// is adapter N-1. NX-OSv: mgmt0 is adapter 0, Ethernet1/N is adapter N.
+	PlatformCisco: {
+		rules: []ifaceRule{
+			rule(`(?:gigabitethernet|gi)0/(\d+)`, func(n []int) (int, int) { return n[0], 0 }),
+			rule(`(?:gigabitethernet|gi)(\d+)`, func(n []int) (int, int) { return n[0] - 1, 0 }),
+			rule(`(?:ethernet|eth|e)1/(\d+)`, func(n []int) (int, int) { return n[0], 0 }),
+			rule(`mgmt0`, func(n []int) (int, int) { return 0, 0 }),
+		},
+		name: func(a, p int) string { return fmt.Sprintf("GigabitEthernet0/%d", a) },
+	},
+	// vMX/vSRX: fxp0 is adapter 0, ge-0/0/N is adapter N+1.
+	PlatformJuniper: {
+		rules: []ifaceRule{
+			rule(`(?:fxp|em)0`, func(n []int) (int, int) { return 0, 0 }),
+			rule(`(?:ge|xe|et)-0/0/(\d+)`, func(n []int) (int, int) { return n[0] + 1, 0 }),
+		},
+		name: func(a, p int) string {
+			if a == 0 {
+				return "fxp0"
+			}
+			return fmt.Sprintf("ge-0/0/%d", a-1)
+		},
+	},
+	// Ethernet switches and hubs expose every port on adapter 0.
+	PlatformSwitch: {
+		rules: []ifaceRule{
+			rule(`(?:ethernet|eth|e|port|p)?(\d+)`, func(n []int) (int, int) { return 0, n[0] }),
+		},
+		name: func(a, p int) string { return fmt.Sprintf("Ethernet%d", p) },
+	},
+	PlatformLinux: {
+		rules: []ifaceRule{
+			rule(`eth(\d+)`, func(n []int) (int, int) { return n[0], 0 }),
+		},
+		name: func(a, p int) string { return fmt.Sprintf("eth%d", a) },
+	},
+	PlatformVPCS: {
+		rules: []ifaceRule{
+			rule(`eth0`, func(n []int) (int, int) { return 0, 0 }),
+		},
+		name: func(a, p int) string { return "eth0" },
+	},
+	// IOU and Dynamips: <type>S/P is adapter (slot) S, port P.
+	PlatformIOU: {
+		rules: []ifaceRule{
+			rule(`(?:ethernet|fastethernet|gigabitethernet|serial|e|fa|gi|s)(\d+)/(\d+)`, func(n []int) (int, int) { return n[0], n[1] }),
+		},
+		name: func(a, p int) string { return fmt.Sprintf("Ethernet%d/%d", a, p) },
+	},
+	PlatformCloud: {
+		name: func(a, p int) string { return fmt.Sprintf("port%d", p) },
+	},
+}
+
+// InterfacePort translates an interface name on the given platform to the
+// GNS3 adapter and port it is cabled to.
+func InterfacePort(platform, iface string) (adapter, port int, err error) {
+	m, ok := interfaceMaps[platform]
+	if !ok {
+		m = interfaceMaps[PlatformArista]
+	}
+	name := strings.ReplaceAll(strings.TrimSpace(iface), " ", "")
+	for _, r := range m.rules {
+		sub := r.re.FindStringSubmatch(name)
+		if sub == nil {
+			continue
+		}
+		nums := make([]int, 0, len(sub)-1)
+		for _, s := range sub[1:] {
+			v, _ := strconv.Atoi(s)
+			nums = append(nums, v)
+		}
+		adapter, port = r.at(nums)
+		if adapter < 0 {
+			break
+		}
+		return adapter, port, nil
+	}
+	return 0, 0, fmt.Errorf("interface %q is not a known %s interface name", iface, platform)
+}
+
+// InterfaceName is the inverse of InterfacePort: the canonical interface
+// name of an adapter/port on the given platform.
+func InterfaceName(platform string, adapter, port int) string {
+	m, ok := interfaceMaps[platform]
+	if !ok {
+		m = interfaceMaps[PlatformArista]
+	}
+	return m.name(adapter, port)
+}
+
+// InterfaceAddress returns the configured interface cabled at adapter/port
+// and its ip_address, if any. Interface names are compared by the port they
+// map to, so Et1 and Ethernet1 are the same interface. Without a config
+// block it returns the canonical name and no address.
+func InterfaceAddress(cfg ConfigList, platform string, adapter, port int) (string, string) {
+	for _, c := range cfg {
+		if c == nil || c.Interface == "" {
+			continue
+		}
+		a, p, err := InterfacePort(platform, c.Interface)
+		if err == nil && a == adapter && p == port {
+			return c.Interface, c.IPAddress
+		}
+	}
+	return InterfaceName(platform, adapter, port), ""
+}
+
+// IsVirtualInterface reports whether iface is a logical interface
+// (loopback, VLAN, tunnel, port-channel) that is never cabled.
+func IsVirtualInterface(iface string) bool {
+	l := strings.ToLower(iface)
+	for _, p := range []string{"loopback", "lo0", "vlan", "tunnel", "port-channel", "ae", "irb", "bvi", "nve", "vxlan"} {
+		if strings.HasPrefix(l, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsManagementInterface reports whether iface is an out-of-band management
+// port (Management1, mgmt0, fxp0, em0), which is reachable without a cable
+// in the topology.
+func IsManagementInterface(iface string) bool {
+	return managementInterface.MatchString(strings.TrimSpace(iface))
+}
+
+var managementInterface = regexp.MustCompile(`(?i)^(?:(?:management|ma|mgmt)\s*\d+|(?:fxp|em)0)$`)
+
+// Platforms maps every declared node name to the platform used to
+// translate its interface names.
+func (t Topology) Platforms() map[string]string {
+	m := make(map[string]string)
+	vendor := func(v string) string {
+		switch strings.ToLower(v) {
+		case PlatformCisco, PlatformJuniper:
+			return strings.ToLower(v)
+		default:
+			return PlatformArista
+		}
+	}
+	for _, r := range t.NetworkDevice.Routers {
+		m[r.Name] = vendor(r.Vendor)
+	}
+	for _, r := range t.Templates.Routers {
+		m[r.Name] = vendor(r.Vendor)
+	}
+	for _, s := range t.Templates.Servers {
+		m[s.Name] = PlatformLinux
+	}
+	for _, s := range t.Switches {
+		m[s.Name] = PlatformSwitch
+	}
+	for _, c := range t.Clouds {
+		m[c.Name] = PlatformCloud
+	}
+	for _, d := range t.Docker {
+		m[d.Name] = PlatformLinux
+	}
+	for _, n := range t.TemplateNodes() {
+		switch n.Kind {
+		case "vpcs":
+			m[n.Name] = PlatformVPCS
+		case "ethernet_hub":
+			m[n.Name] = PlatformSwitch
+		case "nat":
+			m[n.Name] = PlatformCloud
+		}
+	}
+	for _, n := range t.IOU {
+		m[n.Name] = PlatformIOU
+	}
+	for _, n := range t.Dynamips {
+		m[n.Name] = PlatformIOU
+	}
+	return m
+}
+
+// resolveInterfaces fills the adapter and port of every link endpoint that
+// is addressed by interface name, where the file leaves them out. Names that
+// cannot be translated, and an adapter or port in the file that disagrees
+// with the name, are left for validation to report.
+func (t *Topology) resolveInterfaces() {
+	platforms := t.Platforms()
+	for i := range t.Links {
+		for j := range t.Links[i].Endpoints {
+			ep := &t.Links[i].Endpoints[j]
+			if ep.Interface == "" {
+				continue
+			}
+			a, p, err := InterfacePort(platforms[ep.Name], ep.Interface)
+			if err != nil {
+				continue
+			}
+			if !ep.adapterSet {
+				ep.Adapter = a
+			}
+			if !ep.portSet {
+				ep.Port = p
+			}
+		}
+	}
+}
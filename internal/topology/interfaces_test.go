@@ -0,0 +1,144 @@
+package topology
+
+import (
+	"testing"
+)
+
+func TestInterfacePort(t *testing.T) {
+	tests := []struct {
+		platform, iface string
+		adapter, port   int
+		err             bool
+	}{
+		{platform: PlatformArista, iface: "Management1", adapter: 0},
+		{platform: PlatformArista, iface: "Ethernet1", adapter: 1},
+		{platform: PlatformArista, iface: "et12", adapter: 12},
+		{platform: PlatformArista, iface: "Ethernet 3", adapter: 3},
+		{platform: PlatformCisco, iface: "GigabitEthernet0/2", adapter: 2},
+		{platform: PlatformCisco, iface: "Gi0/0", adapter: 0},
+		{platform: PlatformCisco, iface: "GigabitEthernet1", adapter: 0}, // CSR1000v
+		{platform: PlatformCisco, iface: "Ethernet1/4", adapter: 4},      // NX-OSv
+		{platform: PlatformCisco, iface: "mgmt0", adapter: 0},
+		{platform: PlatformJuniper, iface: "fxp0", adapter: 0},
+		{platform: PlatformJuniper, iface: "ge-0/0/0", adapter: 1},
+		{platform: PlatformJuniper, iface: "xe-0/0/3", adapter: 4},
+		{platform: PlatformSwitch, iface: "Ethernet7", adapter: 0, port: 7},
+		{platform: PlatformSwitch, iface: "3", adapter: 0, port: 3},
+		{platform: PlatformLinux, iface: "eth1", adapter: 1},
+		{platform: PlatformVPCS, iface: "eth0", adapter: 0},
+		{platform: PlatformIOU, iface: "Ethernet1/2", adapter: 1, port: 2},
+		{platform: PlatformIOU, iface: "s2/0", adapter: 2, port: 0},
+		{platform: PlatformIOU, iface: "FastEthernet0/1", adapter: 0, port: 1},
+		{platform: "", iface: "Ethernet2", adapter: 2}, // unknown platforms read as Arista
+		{platform: PlatformArista, iface: "GigabitEthernet0/1", err: true},
+		{platform: PlatformCisco, iface: "GigabitEthernet0", err: true}, // CSR numbers from 1
+		{platform: PlatformVPCS, iface: "eth1", err: true},
+		{platform: PlatformCloud, iface: "port0", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.platform+"/"+tt.iface, func(t *testing.T) {
+			a, p, err := InterfacePort(tt.platform, tt.iface)
+			if tt.err {
+				if err == nil {
+					t.Errorf("InterfacePort = %d/%d, want an error", a, p)
+				}
+				return
+			}
+			if err != nil || a != tt.adapter || p != tt.port {
+				t.Errorf("InterfacePort = %d/%d, %v, want %d/%d", a, p, err, tt.adapter, tt.port)
+			}
+		})
+	}
+}
+
+func TestInterfaceNameRoundTrip(t *testing.T) {
+	for _, platform := range []string{PlatformArista, PlatformCisco, PlatformJuniper, PlatformSwitch, PlatformLinux, PlatformIOU} {
+		for adapter := 0; adapter < 4; adapter++ {
+			for port := 0; port < 2; port++ {
+				if platform == PlatformSwitch && adapter > 0 || platform != PlatformSwitch && platform != PlatformIOU && port > 0 {
+					continue // the platform has no such port
+				}
+				name := InterfaceName(platform, adapter, port)
+				a, p, err := InterfacePort(platform, name)
+				if err != nil || a != adapter || p != port {
+					t.Errorf("%s: %d/%d → %q → %d/%d, %v", platform, adapter, port, name, a, p, err)
+				}
+			}
+		}
+	}
+}
+
+func TestIsManagementInterface(t *testing.T) {
+	for iface, want := range map[string]bool{
+		"Management1":        true,
+		"Ma1":                true,
+		"management 1":       true,
+		"mgmt0":              true,
+		"fxp0":               true,
+		"em0":                true,
+		"Ethernet1":          false,
+		"GigabitEthernet0/0": false, // IOSv has no out-of-band port
+		"ge-0/0/0":           false,
+		"em1":                false,
+		"Management1.10":     false,
+	} {
+		if got := IsManagementInterface(iface); got != want {
+			t.Errorf("IsManagementInterface(%q) = %v, want %v", iface, got, want)
+		}
+	}
+}
+
+func TestIsVirtualInterface(t *testing.T) {
+	for iface, want := range map[string]bool{
+		"Loopback0":       true,
+		"lo0":             true,
+		"Vlan10":          true,
+		"Port-Channel1":   true,
+		"ae0":             true,
+		"Ethernet1":       false,
+		"Management1":     false,
+		"GigabitEthernet": false,
+	} {
+		if got := IsVirtualInterface(iface); got != want {
+			t.Errorf("IsVirtualInterface(%q) = %v, want %v", iface, got, want)
+		}
+	}
+}
+
+func TestResolveInterfaces(t *testing.T) {
+	topo, err := decode([]byte(`project:
+  name: lab
+network-device:
+  routers:
+    - {name: R1, vendor: arista, image: veos.qcow2}
+    - {name: R2, vendor: juniper, image: vmx.qcow2}
+switches:
+  - name: SW1
+links:
+  - endpoints:
+      - {name: R1, interface: Ethernet2}
+      - {name: R2, interface: ge-0/0/1}
+  - endpoints:
+      - {name: R1, interface: Ethernet3, adapter: 3, port: 0}
+      - {name: SW1, interface: Ethernet5}
+  - endpoints:
+      - {name: R1, interface: Ethernet4, adapter: 5}
+      - {name: R2, interface: bogus0}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2][2]int{
+		{{2, 0}, {2, 0}},
+		{{3, 0}, {0, 5}},
+		{{5, 0}, {0, 0}}, // the given adapter and an unknown name are left for validation
+	}
+	for i, l := range topo.Links {
+		for j, ep := range l.Endpoints {
+			if got := [2]int{ep.Adapter, ep.Port}; got != want[i][j] {
+				t.Errorf("links[%d].endpoints[%d] (%s %s) = %d/%d, want %d/%d",
+					i, j, ep.Name, ep.Interface, got[0], got[1], want[i][j][0], want[i][j][1])
+			}
+		}
+	}
+}
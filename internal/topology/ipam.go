@@ -0,0 +1,523 @@
+package topology
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// IPAM declares the address pools that router interfaces are numbered from
+// when the topology leaves them out.
+type IPAM struct {
+	P2P        string `yaml:"p2p,omitempty"`        // router-to-router links
+	P2PPrefix  int    `yaml:"p2p_prefix,omitempty"` // 31 (default) or 30
+	Loopback   string `yaml:"loopback,omitempty"`   // one /32 per router, also the router ID
+	Management string `yaml:"management,omitempty"` // management interface of routers with adapter 0 cabled
+}
+
+// IPAMLock records every address IPAM handed out so assignments survive
+// reordering and additions in the topology file.
+type IPAMLock struct {
+	Links      map[string]string `yaml:"links,omitempty"`
+	Loopbacks  map[string]string `yaml:"loopbacks,omitempty"`
+	Management map[string]string `yaml:"management,omitempty"`
+}
+
+const lockHeader = "# Generated by netdevops from the ipam section. Commit this file to keep\n# addresses stable; delete an entry to have it reassigned.\n"
+
+// LockPath returns the IPAM lockfile that belongs to a topology file:
+// topology.yaml → topology.ipam.lock.
+func LockPath(topologyPath string) string {
+	ext := ""
+	if i := strings.LastIndex(topologyPath, "."); i > strings.LastIndex(topologyPath, "/") {
+		ext = topologyPath[i:]
+	}
+	return strings.TrimSuffix(topologyPath, ext) + ".ipam.lock"
+}
+
+// ReadLock reads an IPAM lockfile. A missing file is an empty lock.
+func ReadLock(path string) (IPAMLock, error) {
+	var lock IPAMLock
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, fmt.Errorf("error reading IPAM lockfile %q: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("error parsing IPAM lockfile %q: %w", path, err)
+	}
+	return lock, nil
+}
+
+// WriteLock writes lock to path, leaving the file untouched when nothing
+// changed.
+func WriteLock(path string, lock IPAMLock) error {
+	body, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	data := append([]byte(lockHeader), body...)
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing IPAM lockfile %q: %w", path, err)
+	}
+	return nil
+}
+
+// pool hands out fixed-size blocks of an IPv4 prefix, lowest first.
+type pool struct {
+	name       string
+	prefix     int    // prefix length of each block
+	base, size uint32 // first address and number of addresses
+	block      uint32 // addresses per block
+	first      uint32 // offsets below first are reserved
+	last       uint32 // offsets at or above last are reserved
+	used       map[uint32]bool
+}
+
+func newPool(name, cidr string, prefix int, reserveLow, reserveHigh uint32) (*pool, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil || ipnet.IP.To4() == nil {
+		return nil, fmt.Errorf("ipam.%s: %q is not an IPv4 prefix", name, cidr)
+	}
+	ones, _ := ipnet.Mask.Size()
+	if prefix < ones || prefix > 32 {
+		return nil, fmt.Errorf("ipam.%s: %s cannot be split into /%d blocks", name, cidr, prefix)
+	}
+	size := uint32(1) << (32 - ones)
+	return &pool{
+		name:   name,
+		prefix: prefix,
+		base:   binary.BigEndian.Uint32(ipnet.IP.To4()),
+		size:   size,
+		block:  uint32(1) << (32 - prefix),
+		first:  reserveLow,
+		last:   size - reserveHigh,
+		used:   make(map[uint32]bool),
+	}, nil
+}
+
+// offset returns the block offset of ip inside the pool.
+func (p *pool) offset(ip net.IP) (uint32, bool) {
+	v4 := ip.To4()
+	if v4 == nil {
+		return 0, false
+	}
+	n := binary.BigEndian.Uint32(v4)
+	if n < p.base || n-p.base >= p.size {
+		return 0, false
+	}
+	return (n - p.base) / p.block * p.block, true
+}
+
+// claim marks the block holding ip as taken and returns its first address.
+// It reports false when ip is outside the pool or its block is taken.
+func (p *pool) claim(ip net.IP) (net.IP, bool) {
+	off, ok := p.offset(ip)
+	if !ok || off < p.first || off+p.block > p.last || p.used[off] {
+		return nil, false
+	}
+	p.used[off] = true
+	return p.at(off), true
+}
+
+func (p *pool) at(off uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, p.base+off)
+	return ip
+}
+
+// next takes the lowest free block and returns its first address.
+func (p *pool) next() (net.IP, error) {
+	for off := uint32(0); off+p.block <= p.last; off += p.block {
+		if off < p.first || p.used[off] {
+			continue
+		}
+		p.used[off] = true
+		return p.at(off), nil
+	}
+	return nil, fmt.Errorf("ipam.%s: pool exhausted", p.name)
+}
+
+// addIP returns ip + n.
+func addIP(ip net.IP, n uint32) net.IP {
+	out := make(net.IP, 4)
+	binary.BigEndian.PutUint32(out, binary.BigEndian.Uint32(ip.To4())+n)
+	return out
+}
+
+// ipamRouter is a router IPAM can number, whichever section declares it.
+type ipamRouter struct {
+	name     string
+	platform string
+	config   *ConfigList
+}
+
+// findBlock returns the config block of the interface cabled at
+// adapter/port, if the router has one.
+func (r ipamRouter) findBlock(adapter, port int) *ConfigBlock {
+	for _, c := range *r.config {
+		if c == nil || c.Interface == "" {
+			continue
+		}
+		if a, p, err := InterfacePort(r.platform, c.Interface); err == nil && a == adapter && p == port {
+			return c
+		}
+	}
+	return nil
+}
+
+// setAddress assigns addr to the interface cabled at adapter/port, adding a
+// config block for it when there is none.
+func (r ipamRouter) setAddress(adapter, port int, addr string) {
+	if c := r.findBlock(adapter, port); c != nil {
+		c.IPAddress = addr
+		return
+	}
+	*r.config = append(*r.config, &ConfigBlock{
+		Interface: InterfaceName(r.platform, adapter, port),
+		IPAddress: addr,
+	})
+}
+
+// loopback returns the router's loopback config block, if any.
+func (r ipamRouter) loopback() *ConfigBlock {
+	for _, c := range *r.config {
+		if c != nil && isLoopback(c.Interface) {
+			return c
+		}
+	}
+	return nil
+}
+
+func isLoopback(iface string) bool {
+	l := strings.ToLower(iface)
+	return strings.HasPrefix(l, "loopback") || strings.HasPrefix(l, "lo0")
+}
+
+func loopbackName(platform string) string {
+	if platform == PlatformJuniper {
+		return "lo0"
+	}
+	return "Loopback0"
+}
+
+// LinkKey identifies a cable by its two ends, independent of their order in
+// the file: r1:Ethernet1--r2:Ethernet1.
+func LinkKey(l Link, platforms map[string]string) string {
+	ends := make([]string, 0, len(l.Endpoints))
+	for _, ep := range l.Endpoints {
+		ends = append(ends, ep.Name+":"+InterfaceName(platforms[ep.Name], ep.Adapter, ep.Port))
+	}
+	sort.Strings(ends)
+	return strings.Join(ends, "--")
+}
+
+// AssignAddresses numbers every router interface the topology leaves
+// unaddressed from the ipam pools, reusing the assignments in lock, and
+// returns the lock describing the result. Addresses typed into the file are
+// never changed; they are only reserved so IPAM does not hand them out. A
+// link addressed on one end only gets the other end from the same subnet.
+func (t *Topology) AssignAddresses(lock IPAMLock) (IPAMLock, error) {
+	out := IPAMLock{}
+	if t.IPAM == nil {
+		return out, nil
+	}
+	platforms := t.Platforms()
+
+	routers := make(map[string]ipamRouter)
+	var order []string
+	for i := range t.NetworkDevice.Routers {
+		r := &t.NetworkDevice.Routers[i]
+		if _, dup := routers[r.Name]; !dup {
+			routers[r.Name] = ipamRouter{r.Name, platforms[r.Name], &r.Config}
+			order = append(order, r.Name)
+		}
+	}
+	for i := range t.Templates.Routers {
+		r := &t.Templates.Routers[i]
+		if _, dup := routers[r.Name]; !dup {
+			routers[r.Name] = ipamRouter{r.Name, platforms[r.Name], &r.Config}
+			order = append(order, r.Name)
+		}
+	}
+
+	// Pools. Loopbacks skip the network address; the management pool also
+	// keeps .1 for the gateway and the broadcast address.
+	var p2p, lo, mgmt *pool
+	var err error
+	if t.IPAM.P2P != "" {
+		prefix := t.IPAM.P2PPrefix
+		if prefix == 0 {
+			prefix = 31
+		}
+		if prefix != 30 && prefix != 31 {
+			return out, fmt.Errorf("ipam.p2p_prefix must be 30 or 31, got %d", prefix)
+		}
+		if p2p, err = newPool("p2p", t.IPAM.P2P, prefix, 0, 0); err != nil {
+			return out, err
+		}
+	}
+	if t.IPAM.Loopback != "" {
+		if lo, err = newPool("loopback", t.IPAM.Loopback, 32, 1, 0); err != nil {
+			return out, err
+		}
+	}
+	var mgmtBits int
+	if t.IPAM.Management != "" {
+		if mgmt, err = newPool("management", t.IPAM.Management, 32, 2, 1); err != nil {
+			return out, err
+		}
+		_, ipnet, _ := net.ParseCIDR(t.IPAM.Management)
+		mgmtBits, _ = ipnet.Mask.Size()
+	}
+	pools := []*pool{p2p, lo, mgmt}
+
+	// Reserve every address already typed into the file.
+	taken := make(map[string]bool)
+	reserve := func(ip net.IP) {
+		taken[ip.String()] = true
+		for _, p := range pools {
+			if p != nil {
+				p.claim(ip)
+			}
+		}
+	}
+	for _, name := range order {
+		for _, c := range *routers[name].config {
+			if c == nil || c.IPAddress == "" {
+				continue
+			}
+			if ip, _, err := net.ParseCIDR(c.IPAddress); err == nil {
+				reserve(ip)
+			}
+		}
+	}
+
+	// Work out what needs an address before allocating anything, so locked
+	// assignments are reserved ahead of new ones.
+	type p2pLink struct {
+		key  string
+		a, b Endpoint
+	}
+	var links []p2pLink
+	type mgmtPort struct {
+		router        string
+		adapter, port int
+	}
+	var mgmtPorts []mgmtPort
+	for _, l := range t.Links {
+		if len(l.Endpoints) != 2 {
+			continue
+		}
+		a, b := l.Endpoints[0], l.Endpoints[1]
+		ra, aok := routers[a.Name]
+		rb, bok := routers[b.Name]
+		switch {
+		case aok && bok:
+			hasA, hasB := ra.hasAddress(a), rb.hasAddress(b)
+			if hasA != hasB {
+				// Number the bare end from the subnet of the addressed one.
+				if hasB {
+					a, ra, b, rb = b, rb, a, ra
+				}
+				addr, err := peerAddress(ra.findBlock(a.Adapter, a.Port).IPAddress, taken)
+				if err != nil {
+					return out, fmt.Errorf("link %s: %w", LinkKey(l, platforms), err)
+				}
+				if addr != "" {
+					ip, _, _ := net.ParseCIDR(addr)
+					reserve(ip)
+					rb.setAddress(b.Adapter, b.Port, addr)
+				}
+				continue
+			}
+			if p2p == nil || hasA {
+				continue
+			}
+			// The end that sorts first gets the lower address, so swapping
+			// the endpoints in the file does not renumber the link.
+			if a.Name+":"+InterfaceName(ra.platform, a.Adapter, a.Port) > b.Name+":"+InterfaceName(rb.platform, b.Adapter, b.Port) {
+				a, b = b, a
+			}
+			links = append(links, p2pLink{LinkKey(l, platforms), a, b})
+		case mgmt != nil:
+			for _, ep := range []Endpoint{a, b} {
+				if r, ok := routers[ep.Name]; ok && ep.Adapter == 0 && !r.hasAddress(ep) {
+					mgmtPorts = append(mgmtPorts, mgmtPort{ep.Name, ep.Adapter, ep.Port})
+				}
+			}
+		}
+	}
+	var loopbacks []string
+	if lo != nil {
+		for _, name := range order {
+			if c := routers[name].loopback(); c == nil || c.IPAddress == "" {
+				loopbacks = append(loopbacks, name)
+			}
+		}
+	}
+
+	// Locked assignments first, then the lowest free block for the rest.
+	linkNet := make(map[string]net.IP)
+	for _, l := range links {
+		if ip, _, err := net.ParseCIDR(lock.Links[l.key]); err == nil {
+			if block, ok := p2p.claim(ip); ok {
+				linkNet[l.key] = block
+			}
+		}
+	}
+	loNet := make(map[string]net.IP)
+	for _, name := range loopbacks {
+		if ip, _, err := net.ParseCIDR(lock.Loopbacks[name]); err == nil {
+			if block, ok := lo.claim(ip); ok {
+				loNet[name] = block
+			}
+		}
+	}
+	mgmtIP := make(map[string]net.IP)
+	for _, m := range mgmtPorts {
+		if ip, _, err := net.ParseCIDR(lock.Management[m.router]); err == nil {
+			if block, ok := mgmt.claim(ip); ok {
+				mgmtIP[m.router] = block
+			}
+		}
+	}
+
+	for _, l := range links {
+		ip, ok := linkNet[l.key]
+		if !ok {
+			if ip, err = p2p.next(); err != nil {
+				return out, err
+			}
+		}
+		// A /31 uses both addresses; a /30 skips network and broadcast.
+		first := uint32(0)
+		if p2p.block == 4 {
+			first = 1
+		}
+		bits := p2p.prefix
+		routers[l.a.Name].setAddress(l.a.Adapter, l.a.Port, fmt.Sprintf("%s/%d", addIP(ip, first), bits))
+		routers[l.b.Name].setAddress(l.b.Adapter, l.b.Port, fmt.Sprintf("%s/%d", addIP(ip, first+1), bits))
+		if out.Links == nil {
+			out.Links = make(map[string]string)
+		}
+		out.Links[l.key] = fmt.Sprintf("%s/%d", ip, bits)
+	}
+	for _, name := range loopbacks {
+		ip, ok := loNet[name]
+		if !ok {
+			if ip, err = lo.next(); err != nil {
+				return out, err
+			}
+		}
+		r := routers[name]
+		addr := fmt.Sprintf("%s/32", ip)
+		if c := r.loopback(); c != nil {
+			c.IPAddress = addr
+		} else {
+			*r.config = append(*r.config, &ConfigBlock{Interface: loopbackName(r.platform), IPAddress: addr})
+		}
+		if out.Loopbacks == nil {
+			out.Loopbacks = make(map[string]string)
+		}
+		out.Loopbacks[name] = addr
+	}
+	for _, m := range mgmtPorts {
+		if _, done := out.Management[m.router]; done {
+			continue
+		}
+		ip, ok := mgmtIP[m.router]
+		if !ok {
+			if ip, err = mgmt.next(); err != nil {
+				return out, err
+			}
+		}
+		addr := fmt.Sprintf("%s/%d", ip, mgmtBits)
+		routers[m.router].setAddress(m.adapter, m.port, addr)
+		if out.Management == nil {
+			out.Management = make(map[string]string)
+		}
+		out.Management[m.router] = addr
+	}
+
+	for _, name := range order {
+		routers[name].fillRouting()
+	}
+	return out, nil
+}
+
+// peerAddress returns the lowest address of addr's subnet that is not taken,
+// for the far end of a link addressed on one end only. /31 subnets use both
+// addresses; wider ones skip the network and broadcast addresses. It returns
+// "" when addr is not a CIDR, which validation reports.
+func peerAddress(addr string, taken map[string]bool) (string, error) {
+	_, ipnet, err := net.ParseCIDR(addr)
+	if err != nil || ipnet.IP.To4() == nil {
+		return "", nil
+	}
+	ones, _ := ipnet.Mask.Size()
+	size := uint32(1) << (32 - ones)
+	first, last := uint32(0), size
+	if size > 2 {
+		first, last = 1, size-1
+	}
+	for off := first; off < last; off++ {
+		ip := addIP(ipnet.IP, off)
+		if !taken[ip.String()] {
+			return fmt.Sprintf("%s/%d", ip, ones), nil
+		}
+	}
+	return "", fmt.Errorf("no free address left in %s for the other end", ipnet)
+}
+
+// hasAddress reports whether the interface cabled at ep already carries an
+// address typed into the file.
+func (r ipamRouter) hasAddress(ep Endpoint) bool {
+	c := r.findBlock(ep.Adapter, ep.Port)
+	return c != nil && c.IPAddress != ""
+}
+
+// fillRouting sets the OSPF and BGP router IDs to the loopback address and
+// advertises every addressed interface in OSPF when they are left out.
+func (r ipamRouter) fillRouting() {
+	routerID := ""
+	if c := r.loopback(); c != nil && c.IPAddress != "" {
+		routerID = strings.Split(c.IPAddress, "/")[0]
+	}
+	var networks []string
+	for _, c := range *r.config {
+		if c == nil || c.IPAddress == "" {
+			continue
+		}
+		if _, ipnet, err := net.ParseCIDR(c.IPAddress); err == nil {
+			networks = append(networks, ipnet.String())
+		}
+	}
+	for _, c := range *r.config {
+		if c == nil {
+			continue
+		}
+		if c.OSPF != nil {
+			if c.OSPF.RouterID == "" {
+				c.OSPF.RouterID = routerID
+			}
+			if len(c.OSPF.Networks) == 0 {
+				c.OSPF.Networks = networks
+			}
+		}
+		if c.BGP != nil && c.BGP.RouterID == "" {
+			c.BGP.RouterID = routerID
+		}
+	}
+}
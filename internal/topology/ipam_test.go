@@ -0,0 +1,244 @@
+package topology
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ipamTopology is an Arista triangle R1–R2–R3 cabled on Ethernet1/Ethernet2,
+// with R1's Management1 on a switch, numbered from the given ipam section.
+// Extra config blocks per router are appended verbatim.
+func ipamTopology(ipam string, config map[string]string) string {
+	var b strings.Builder
+	b.WriteString("project:\n  name: lab\nipam:\n" + ipam + "\nnetwork-device:\n  routers:\n")
+	for _, r := range []string{"R1", "R2", "R3"} {
+		b.WriteString("  - name: " + r + "\n    vendor: arista\n    image: veos.qcow2\n    config:\n")
+		b.WriteString(config[r])
+	}
+	b.WriteString(`switches:
+  - name: SW1
+links:
+  - endpoints:
+      - {name: R1, interface: Ethernet1}
+      - {name: R2, interface: Ethernet1}
+  - endpoints:
+      - {name: R2, interface: Ethernet2}
+      - {name: R3, interface: Ethernet1}
+  - endpoints:
+      - {name: R3, interface: Ethernet2}
+      - {name: R1, interface: Ethernet2}
+  - endpoints:
+      - {name: R1, interface: Management1}
+      - {name: SW1, adapter: 0, port: 0}
+`)
+	return b.String()
+}
+
+// addressOf returns the address of a router interface, matched by name or,
+// for cabled interfaces, by the port it maps to.
+func addressOf(t Topology, router, iface string) string {
+	platforms := t.Platforms()
+	for _, r := range t.NetworkDevice.Routers {
+		if r.Name != router {
+			continue
+		}
+		for _, c := range r.Config {
+			if c != nil && strings.EqualFold(c.Interface, iface) {
+				return c.IPAddress
+			}
+		}
+		if a, p, err := InterfacePort(platforms[router], iface); err == nil {
+			_, addr := InterfaceAddress(r.Config, platforms[router], a, p)
+			return addr
+		}
+	}
+	return ""
+}
+
+func TestAssignAddresses(t *testing.T) {
+	tests := []struct {
+		name   string
+		ipam   string
+		config map[string]string
+		lock   IPAMLock
+		want   map[string]string // router:interface → address
+	}{
+		{
+			name: "p2p /31 from the lowest free block",
+			ipam: "  p2p: 10.0.0.0/24",
+			want: map[string]string{
+				"R1:Ethernet1": "10.0.0.0/31", "R2:Ethernet1": "10.0.0.1/31",
+				"R2:Ethernet2": "10.0.0.2/31", "R3:Ethernet1": "10.0.0.3/31",
+				"R1:Ethernet2": "10.0.0.4/31", "R3:Ethernet2": "10.0.0.5/31",
+			},
+		},
+		{
+			name: "p2p /30 skips network and broadcast",
+			ipam: "  p2p: 10.0.0.0/24\n  p2p_prefix: 30",
+			want: map[string]string{
+				"R1:Ethernet1": "10.0.0.1/30", "R2:Ethernet1": "10.0.0.2/30",
+				"R2:Ethernet2": "10.0.0.5/30", "R3:Ethernet1": "10.0.0.6/30",
+			},
+		},
+		{
+			name: "locked link keeps its block",
+			ipam: "  p2p: 10.0.0.0/24",
+			lock: IPAMLock{Links: map[string]string{"R2:Ethernet2--R3:Ethernet1": "10.0.0.8/31"}},
+			want: map[string]string{
+				"R1:Ethernet1": "10.0.0.0/31",
+				"R2:Ethernet2": "10.0.0.8/31", "R3:Ethernet1": "10.0.0.9/31",
+				"R1:Ethernet2": "10.0.0.2/31",
+			},
+		},
+		{
+			name:   "typed address is reserved, not handed out",
+			ipam:   "  p2p: 10.0.0.0/24",
+			config: map[string]string{"R2": "      - {interface: Ethernet9, ip_address: 10.0.0.0/31}\n"},
+			want: map[string]string{
+				"R2:Ethernet9": "10.0.0.0/31",
+				"R1:Ethernet1": "10.0.0.2/31", "R2:Ethernet1": "10.0.0.3/31",
+			},
+		},
+		{
+			name:   "bare end numbered from the addressed end's subnet",
+			ipam:   "  p2p: 10.0.0.0/24",
+			config: map[string]string{"R1": "      - {interface: Ethernet1, ip_address: 172.16.0.1/24}\n"},
+			want: map[string]string{
+				"R1:Ethernet1": "172.16.0.1/24", "R2:Ethernet1": "172.16.0.2/24",
+				"R2:Ethernet2": "10.0.0.0/31",
+			},
+		},
+		{
+			name:   "bare end of a /31 gets the other address",
+			ipam:   "  p2p: 10.0.0.0/24",
+			config: map[string]string{"R3": "      - {interface: Ethernet1, ip_address: 192.168.9.1/31}\n"},
+			want:   map[string]string{"R3:Ethernet1": "192.168.9.1/31", "R2:Ethernet2": "192.168.9.0/31"},
+		},
+		{
+			name: "loopbacks skip the network address",
+			ipam: "  loopback: 192.168.255.0/24",
+			want: map[string]string{
+				"R1:Loopback0": "192.168.255.1/32", "R2:Loopback0": "192.168.255.2/32", "R3:Loopback0": "192.168.255.3/32",
+			},
+		},
+		{
+			name: "management skips the gateway",
+			ipam: "  management: 172.30.0.0/24",
+			want: map[string]string{"R1:Management1": "172.30.0.2/24"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo, err := decode([]byte(ipamTopology(tt.ipam, tt.config)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := topo.AssignAddresses(tt.lock); err != nil {
+				t.Fatal(err)
+			}
+			for at, want := range tt.want {
+				router, iface, _ := strings.Cut(at, ":")
+				if got := addressOf(topo, router, iface); got != want {
+					t.Errorf("%s = %q, want %q", at, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAssignAddressesStable(t *testing.T) {
+	// Swapping the ends of a link in the file must not renumber it.
+	src := ipamTopology("  p2p: 10.0.0.0/24", nil)
+	swapped := strings.Replace(src,
+		"      - {name: R1, interface: Ethernet1}\n      - {name: R2, interface: Ethernet1}",
+		"      - {name: R2, interface: Ethernet1}\n      - {name: R1, interface: Ethernet1}", 1)
+	var locks []IPAMLock
+	for _, s := range []string{src, swapped} {
+		topo, err := decode([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lock, err := topo.AssignAddresses(IPAMLock{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := addressOf(topo, "R1", "Ethernet1"); got != "10.0.0.0/31" {
+			t.Errorf("R1:Ethernet1 = %q, want 10.0.0.0/31", got)
+		}
+		locks = append(locks, lock)
+	}
+	if a, b := locks[0].Links["R1:Ethernet1--R2:Ethernet1"], locks[1].Links["R1:Ethernet1--R2:Ethernet1"]; a != "10.0.0.0/31" || a != b {
+		t.Errorf("locked link = %q and %q, want 10.0.0.0/31 for both", a, b)
+	}
+}
+
+func TestAssignAddressesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		ipam   string
+		config map[string]string
+		want   string
+	}{
+		{
+			name: "p2p prefix other than 30 or 31",
+			ipam: "  p2p: 10.0.0.0/24\n  p2p_prefix: 29",
+			want: "ipam.p2p_prefix must be 30 or 31",
+		},
+		{
+			name: "pool that is not a prefix",
+			ipam: "  loopback: 10.0.0.1",
+			want: `ipam.loopback: "10.0.0.1" is not an IPv4 prefix`,
+		},
+		{
+			name: "exhausted pool",
+			ipam: "  p2p: 10.0.0.0/31",
+			want: "ipam.p2p: pool exhausted",
+		},
+		{
+			name: "no address left for the bare end",
+			ipam: "  p2p: 10.0.0.0/24",
+			config: map[string]string{
+				"R1": "      - {interface: Ethernet1, ip_address: 10.9.0.0/31}\n",
+				"R3": "      - {interface: Ethernet9, ip_address: 10.9.0.1/31}\n",
+			},
+			want: "link R1:Ethernet1--R2:Ethernet1: no free address left in 10.9.0.0/31",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			topo, err := decode([]byte(ipamTopology(tt.ipam, tt.config)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = topo.AssignAddresses(IPAMLock{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLockfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.yaml")
+	if err := os.WriteFile(path, []byte(ipamTopology("  p2p: 10.0.0.0/24", nil)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReadOnly(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(LockPath(path)); !os.IsNotExist(err) {
+		t.Fatalf("LoadReadOnly wrote %s", LockPath(path))
+	}
+	if _, err := Load(path); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := ReadLock(LockPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lock.Links["R2:Ethernet2--R3:Ethernet1"]; got != "10.0.0.2/31" {
+		t.Errorf("locked R2–R3 link = %q, want 10.0.0.2/31", got)
+	}
+}
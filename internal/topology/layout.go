@@ -0,0 +1,419 @@
+package topology
+
+import (
+	"math"
+	"sort"
+)
+
+// Layout algorithms for project.layout.
+const (
+	LayoutAuto    = "auto"    // layered for fabrics, force-directed otherwise
+	LayoutLayered = "layered" // rows, top to bottom
+	LayoutForce   = "force"   // force-directed
+	LayoutNone    = "none"    // leave unplaced nodes at 0,0
+)
+
+// Canvas spacing between neighbouring nodes, in GNS3 scene units.
+const (
+	layoutSpacingX = 180
+	layoutSpacingY = 160
+)
+
+// Position is an optional placement on the GNS3 canvas. Nodes without one
+// are placed by the layout engine when the topology is loaded, so after
+// Load every node has both X and Y set.
+type Position struct {
+	X *int `yaml:"x,omitempty"`
+	Y *int `yaml:"y,omitempty"`
+
+	computed bool // set by the layout engine, not typed into the file
+}
+
+// Placed reports whether the node has a position.
+func (p Position) Placed() bool { return p.X != nil && p.Y != nil }
+
+// Computed reports whether the position was chosen by the layout engine
+// rather than written in the file. Computed positions move whenever the
+// topology around the node changes.
+func (p Position) Computed() bool { return p.computed }
+
+// XY returns the position, 0,0 when unplaced.
+func (p Position) XY() (int, int) {
+	if !p.Placed() {
+		return 0, 0
+	}
+	return *p.X, *p.Y
+}
+
+func (p *Position) set(x, y int) {
+	p.X, p.Y = &x, &y
+}
+
+// positions returns every node's position in declaration order.
+func (t *Topology) positions() ([]string, map[string]*Position) {
+	var names []string
+	pos := make(map[string]*Position)
+	add := func(name string, p *Position) {
+		if name == "" || pos[name] != nil {
+			return
+		}
+		names = append(names, name)
+		pos[name] = p
+	}
+	for i := range t.NetworkDevice.Routers {
+		add(t.NetworkDevice.Routers[i].Name, &t.NetworkDevice.Routers[i].Position)
+	}
+	for i := range t.Templates.Routers {
+		add(t.Templates.Routers[i].Name, &t.Templates.Routers[i].Position)
+	}
+	for i := range t.Templates.Servers {
+		add(t.Templates.Servers[i].Name, &t.Templates.Servers[i].Position)
+	}
+	for i := range t.Switches {
+		add(t.Switches[i].Name, &t.Switches[i].Position)
+	}
+	for i := range t.Clouds {
+		add(t.Clouds[i].Name, &t.Clouds[i].Position)
+	}
+	for i := range t.Docker {
+		add(t.Docker[i].Name, &t.Docker[i].Position)
+	}
+	for _, group := range [][]TemplateNode{t.VPCS, t.NAT, t.Hubs} {
+		for i := range group {
+			add(group[i].Name, &group[i].Position)
+		}
+	}
+	for i := range t.IOU {
+		add(t.IOU[i].Name, &t.IOU[i].Position)
+	}
+	for i := range t.Dynamips {
+		add(t.Dynamips[i].Name, &t.Dynamips[i].Position)
+	}
+	return names, pos
+}
+
+// layout places every node that has no x/y of its own.
+func (t *Topology) layout() {
+	names, pos := t.positions()
+	if len(names) == 0 {
+		return
+	}
+	mode := t.Project.Layout
+	if mode == "" || mode == LayoutAuto {
+		mode = LayoutForce
+		if len(t.tiers) > 0 {
+			mode = LayoutLayered
+		}
+	}
+
+	adj := make(map[string][]string)
+	for _, l := range t.Links {
+		if len(l.Endpoints) != 2 {
+			continue
+		}
+		a, b := l.Endpoints[0].Name, l.Endpoints[1].Name
+		if pos[a] == nil || pos[b] == nil || a == b {
+			continue
+		}
+		adj[a] = append(adj[a], b)
+		adj[b] = append(adj[b], a)
+	}
+
+	var xy map[string][2]float64
+	switch mode {
+	case LayoutNone:
+		xy = make(map[string][2]float64)
+	case LayoutLayered:
+		xy = layeredLayout(names, adj, t.tiers)
+	default:
+		xy = forceLayout(names, adj, pos)
+	}
+	for _, name := range names {
+		if pos[name].Placed() {
+			continue
+		}
+		p := xy[name]
+		pos[name].set(int(math.Round(p[0])), int(math.Round(p[1])))
+		pos[name].computed = true
+	}
+}
+
+// layeredLayout puts nodes in rows. Fabric tiers give the first rows
+// (spines, then leaves); without them the first declared node starts the
+// first row. Every other node goes one row below its nearest neighbour,
+// ordered by the average position of its neighbours above to keep links
+// short. Unconnected nodes share the last row.
+func layeredLayout(names []string, adj map[string][]string, tiers map[string]int) map[string][2]float64 {
+	layer := make(map[string]int)
+	var queue []string
+	for _, n := range names {
+		if l, ok := tiers[n]; ok {
+			layer[n] = l
+			queue = append(queue, n)
+		}
+	}
+	if len(queue) == 0 {
+		layer[names[0]] = 0
+		queue = append(queue, names[0])
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range adj[n] {
+			if _, seen := layer[m]; !seen {
+				layer[m] = layer[n] + 1
+				queue = append(queue, m)
+			}
+		}
+	}
+	last := 0
+	for _, l := range layer {
+		if l > last {
+			last = l
+		}
+	}
+	var rows [][]string
+	for _, n := range names {
+		l, ok := layer[n]
+		if !ok {
+			l = last + 1
+		}
+		for len(rows) <= l {
+			rows = append(rows, nil)
+		}
+		rows[l] = append(rows[l], n)
+	}
+
+	xy := make(map[string][2]float64)
+	for r, row := range rows {
+		if r > 0 {
+			// Barycenter ordering against the rows already placed.
+			bary := make(map[string]float64)
+			for i, n := range row {
+				sum, cnt := 0.0, 0
+				for _, m := range adj[n] {
+					if p, ok := xy[m]; ok {
+						sum += p[0]
+						cnt++
+					}
+				}
+				bary[n] = float64(i) * 1e-6 // keep declaration order on ties
+				if cnt > 0 {
+					bary[n] += sum / float64(cnt)
+				}
+			}
+			sort.SliceStable(row, func(i, j int) bool { return bary[row[i]] < bary[row[j]] })
+		}
+		for i, n := range row {
+			x := (float64(i) - float64(len(row)-1)/2) * layoutSpacingX
+			xy[n] = [2]float64{x, float64(r) * layoutSpacingY}
+		}
+	}
+	return xy
+}
+
+// forceLayout lays out every connected group of nodes on its own with
+// forceGroup. Groups with a pinned node stay where their pins put them; the
+// others are packed side by side in rows, right of any pinned group, so
+// unlinked nodes and islands sit next to the rest instead of drifting off.
+func forceLayout(names []string, adj map[string][]string, pos map[string]*Position) map[string][2]float64 {
+	xy := make(map[string][2]float64, len(names))
+	var free []layoutBox
+	pinnedRight, pinnedTop, anyPinned := 0.0, 0.0, false
+	for _, group := range components(names, adj) {
+		pinned := false
+		for _, name := range group {
+			pinned = pinned || pos[name].Placed()
+		}
+		g := forceGroup(group, adj, pos)
+		if pinned {
+			for name, p := range g {
+				xy[name] = p
+				if !anyPinned {
+					pinnedRight, pinnedTop, anyPinned = p[0], p[1], true
+				}
+				pinnedRight, pinnedTop = math.Max(pinnedRight, p[0]), math.Min(pinnedTop, p[1])
+			}
+			continue
+		}
+		free = append(free, newLayoutBox(group, g))
+	}
+	if len(free) == 0 {
+		return xy
+	}
+
+	// Shelf packing: the widest groups first, rows about as wide as the
+	// packed area is tall.
+	sort.SliceStable(free, func(i, j int) bool { return free[i].w*free[i].h > free[j].w*free[j].h })
+	area, widest := 0.0, 0.0
+	for _, b := range free {
+		area += (b.w + layoutSpacingX) * (b.h + layoutSpacingY)
+		widest = math.Max(widest, b.w+layoutSpacingX)
+	}
+	rowWidth := math.Max(widest, math.Sqrt(area))
+	x, y, rowHeight := 0.0, 0.0, 0.0
+	for i := range free {
+		b := &free[i]
+		if x > 0 && x+b.w > rowWidth {
+			x, y, rowHeight = 0, y+rowHeight+layoutSpacingY, 0
+		}
+		b.x, b.y = x, y
+		x += b.w + layoutSpacingX
+		rowHeight = math.Max(rowHeight, b.h)
+	}
+
+	// Centre the packing on the origin, or start it right of the pinned
+	// nodes, level with the topmost.
+	dx, dy := -(rowWidth-layoutSpacingX)/2, -(y+rowHeight)/2
+	if anyPinned {
+		dx, dy = pinnedRight+2*layoutSpacingX, pinnedTop
+	}
+	for _, b := range free {
+		for name, p := range b.nodes {
+			xy[name] = [2]float64{p[0] - b.minX + b.x + dx, p[1] - b.minY + b.y + dy}
+		}
+	}
+	return xy
+}
+
+// layoutBox is the bounding box of a laid out group and its place in the
+// packing.
+type layoutBox struct {
+	nodes      map[string][2]float64
+	minX, minY float64
+	w, h       float64
+	x, y       float64
+}
+
+func newLayoutBox(group []string, xy map[string][2]float64) layoutBox {
+	b := layoutBox{nodes: xy, minX: math.Inf(1), minY: math.Inf(1)}
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, name := range group {
+		p := xy[name]
+		b.minX, b.minY = math.Min(b.minX, p[0]), math.Min(b.minY, p[1])
+		maxX, maxY = math.Max(maxX, p[0]), math.Max(maxY, p[1])
+	}
+	b.w, b.h = maxX-b.minX, maxY-b.minY
+	return b
+}
+
+// components splits names into connected groups, each in declaration order,
+// ordered by their first node.
+func components(names []string, adj map[string][]string) [][]string {
+	group := make(map[string]int, len(names))
+	var out [][]string
+	for _, start := range names {
+		if _, seen := group[start]; seen {
+			continue
+		}
+		id := len(out)
+		group[start] = id
+		queue := []string{start}
+		for len(queue) > 0 {
+			n := queue[0]
+			queue = queue[1:]
+			for _, m := range adj[n] {
+				if _, seen := group[m]; !seen {
+					group[m] = id
+					queue = append(queue, m)
+				}
+			}
+		}
+		out = append(out, nil)
+	}
+	for _, name := range names {
+		out[group[name]] = append(out[group[name]], name)
+	}
+	return out
+}
+
+// forceGroup is a deterministic Fruchterman-Reingold layout of one connected
+// group: nodes start on a circle in declaration order, links pull, nodes
+// push each other away, and a weak pull toward the group's centre and a
+// square frame keep it compact. Nodes with an explicit position are pinned
+// and the group is centred on them.
+func forceGroup(names []string, adj map[string][]string, pos map[string]*Position) map[string][2]float64 {
+	n := len(names)
+	k := float64(layoutSpacingX)
+	radius := math.Max(k, k*float64(n)/(2*math.Pi))
+	frame := k * math.Max(1, math.Sqrt(float64(n)))
+	p := make([][2]float64, n)
+	pinned := make([]bool, n)
+	index := make(map[string]int, n)
+	var centre [2]float64
+	pins := 0
+	for i, name := range names {
+		index[name] = i
+		if pos[name].Placed() {
+			x, y := pos[name].XY()
+			p[i] = [2]float64{float64(x), float64(y)}
+			pinned[i] = true
+			centre[0] += float64(x)
+			centre[1] += float64(y)
+			pins++
+		}
+	}
+	if pins > 0 {
+		centre[0] /= float64(pins)
+		centre[1] /= float64(pins)
+	}
+	for i := range names {
+		if !pinned[i] {
+			a := 2 * math.Pi * float64(i) / float64(n)
+			p[i] = [2]float64{centre[0] + radius*math.Cos(a), centre[1] + radius*math.Sin(a)}
+		}
+	}
+
+	const (
+		iterations = 300
+		gravity    = 0.1 // pull toward the centre, relative to a link's
+	)
+	temp := radius / 2
+	for it := 0; it < iterations; it++ {
+		disp := make([][2]float64, n)
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				dx, dy := p[i][0]-p[j][0], p[i][1]-p[j][1]
+				d := math.Max(math.Hypot(dx, dy), 1)
+				f := k * k / d
+				disp[i][0] += dx / d * f
+				disp[i][1] += dy / d * f
+				disp[j][0] -= dx / d * f
+				disp[j][1] -= dy / d * f
+			}
+		}
+		for i, name := range names {
+			for _, m := range adj[name] {
+				j := index[m]
+				dx, dy := p[i][0]-p[j][0], p[i][1]-p[j][1]
+				d := math.Max(math.Hypot(dx, dy), 1)
+				f := d * d / k
+				disp[i][0] -= dx / d * f
+				disp[i][1] -= dy / d * f
+			}
+			dx, dy := p[i][0]-centre[0], p[i][1]-centre[1]
+			d := math.Max(math.Hypot(dx, dy), 1)
+			f := gravity * d * d / k
+			disp[i][0] -= dx / d * f
+			disp[i][1] -= dy / d * f
+		}
+		for i := range p {
+			if pinned[i] {
+				continue
+			}
+			d := math.Max(math.Hypot(disp[i][0], disp[i][1]), 1e-9)
+			step := math.Min(d, temp)
+			p[i][0] += disp[i][0] / d * step
+			p[i][1] += disp[i][1] / d * step
+			p[i][0] = math.Max(centre[0]-frame, math.Min(centre[0]+frame, p[i][0]))
+			p[i][1] = math.Max(centre[1]-frame, math.Min(centre[1]+frame, p[i][1]))
+		}
+		temp *= 0.98
+	}
+
+	xy := make(map[string][2]float64, n)
+	for i, name := range names {
+		xy[name] = p[i]
+	}
+	return xy
+}
@@ -0,0 +1,274 @@
+package topology
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultGNS3Server is used when project.gns3_server is left empty.
+const DefaultGNS3Server = "http://localhost:3080"
+
+// DefaultQemuResources are the built-in compute settings of a QEMU router.
+var DefaultQemuResources = QemuResources{
+	RAM:         2048,
+	CPUs:        2,
+	Adapters:    10,
+	AdapterType: "e1000",
+	Platform:    "x86_64",
+	ConsoleType: "telnet",
+}
+
+// DefaultIOU holds the GNS3 defaults of an IOU node built from its image.
+var DefaultIOU = IOUNode{RAM: 256, NVRAM: 128, EthernetAdapters: 2, SerialAdapters: 2}
+
+// Built-in GNS3 templates used when a node section omits template_name.
+const (
+	DefaultVPCSTemplate = "VPCS"
+	DefaultNATTemplate  = "NAT"
+	DefaultHubTemplate  = "Ethernet hub"
+)
+
+// ParseError reports that a topology file could be read but not decoded.
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string { return e.Err.Error() }
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Load reads the YAML file at path and returns the typed topology with
+// defaults resolved. Every command goes through Load so the same file is
+// understood identically everywhere.
+//
+// When the topology has an ipam section, addresses are assigned from the
+// lockfile next to path and the lockfile is updated with the result.
+func Load(path string) (Topology, error) {
+	return load(path, true)
+}
+
+// LoadReadOnly is Load without updating the IPAM lockfile: addresses not in
+// the lock are assigned for this read only. Use it to inspect revisions of
+// a topology, e.g. one piped from git show.
+func LoadReadOnly(path string) (Topology, error) {
+	return load(path, false)
+}
+
+func load(path string, writeLock bool) (Topology, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Topology{}, fmt.Errorf("error reading YAML file %q: %w", path, err)
+	}
+	t, err := decode(data)
+	if err != nil || t.IPAM == nil {
+		return t, err
+	}
+	lockPath := LockPath(path)
+	lock, err := ReadLock(lockPath)
+	if err != nil {
+		return t, err
+	}
+	if lock, err = t.AssignAddresses(lock); err != nil || !writeLock {
+		return t, err
+	}
+	return t, WriteLock(lockPath, lock)
+}
+
+// ProjectName reads only project.name from the YAML file at path, without
+// validating or resolving the rest, so it works on a file that Load rejects.
+func ProjectName(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading YAML file %q: %w", path, err)
+	}
+	var head struct {
+		Project struct {
+			Name string `yaml:"name"`
+		} `yaml:"project"`
+	}
+	if err := yaml.Unmarshal(data, &head); err != nil {
+		return "", &ParseError{Err: err}
+	}
+	if head.Project.Name == "" {
+		return "", fmt.Errorf("%s: project.name is not set", path)
+	}
+	return head.Project.Name, nil
+}
+
+// Parse decodes raw topology YAML and resolves defaults. IPAM addresses are
+// assigned afresh, without a lockfile.
+func Parse(data []byte) (Topology, error) {
+	t, err := decode(data)
+	if err != nil {
+		return t, err
+	}
+	_, err = t.AssignAddresses(IPAMLock{})
+	return t, err
+}
+
+// Marshal renders the resolved topology (defaults, derived MACs and IPAM
+// addresses filled in) back to YAML, for consumers that read the file
+// themselves such as the ZTP server.
+func (t Topology) Marshal() ([]byte, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(t); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return []byte(b.String()), nil
+}
+
+func decode(data []byte) (Topology, error) {
+	var t Topology
+	if err := CheckVersion(data); err != nil {
+		return t, err
+	}
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return t, &ParseError{Err: err}
+	}
+	t.Version = CurrentVersion
+	t.applyDefaults()
+	return t, nil
+}
+
+// applyDefaults fills in every value the rest of the pipeline relies on
+// being set, so callers never have to re-derive them.
+func (t *Topology) applyDefaults() {
+	if t.Project.GNS3Server == "" {
+		t.Project.GNS3Server = DefaultGNS3Server
+	}
+
+	t.Project.Defaults.inherit(DefaultQemuResources)
+	t.expandFabric()
+	t.assignMACs()
+	for i := range t.NetworkDevice.Routers {
+		r := &t.NetworkDevice.Routers[i]
+		if r.Hostname == "" {
+			r.Hostname = r.Name
+		}
+		r.QemuResources.inherit(t.Project.Defaults)
+	}
+	for i := range t.Templates.Routers {
+		r := &t.Templates.Routers[i]
+		if r.Hostname == "" {
+			r.Hostname = r.Name
+		}
+		if r.TemplateName == "" {
+			r.TemplateName = r.Template
+		}
+	}
+
+	for i := range t.Docker {
+		d := &t.Docker[i]
+		if d.Adapters == 0 {
+			d.Adapters = 1
+		}
+		if d.ConsoleType == "" {
+			d.ConsoleType = "telnet"
+		}
+	}
+	defaultTemplateNodes(t.VPCS, "vpcs", DefaultVPCSTemplate)
+	defaultTemplateNodes(t.NAT, "nat", DefaultNATTemplate)
+	defaultTemplateNodes(t.Hubs, "ethernet_hub", DefaultHubTemplate)
+	for i := range t.IOU {
+		n := &t.IOU[i]
+		if n.Image == "" {
+			continue
+		}
+		if n.RAM == 0 {
+			n.RAM = DefaultIOU.RAM
+		}
+		if n.NVRAM == 0 {
+			n.NVRAM = DefaultIOU.NVRAM
+		}
+		if n.EthernetAdapters == 0 && n.SerialAdapters == 0 {
+			n.EthernetAdapters, n.SerialAdapters = DefaultIOU.EthernetAdapters, DefaultIOU.SerialAdapters
+		}
+	}
+
+	for _, srv := range t.Templates.Servers {
+		if srv.ZTPServer != "" {
+			t.ZTPServer = srv.ZTPServer
+			break
+		}
+	}
+
+	t.resolveInterfaces()
+	t.layout()
+}
+
+// ConfiguredRouters returns every router that can carry a config block:
+// QEMU routers from network-device first, then template routers that are
+// not already declared there.
+func (t Topology) ConfiguredRouters() []Router {
+	seen := make(map[string]bool)
+	var out []Router
+	for _, r := range t.NetworkDevice.Routers {
+		seen[r.Name] = true
+		out = append(out, Router{
+			Name:     r.Name,
+			Hostname: r.Hostname,
+			Vendor:   r.Vendor,
+			Config:   r.Config,
+			Start:    true,
+			Position: r.Position,
+		})
+	}
+	for _, r := range t.Templates.Routers {
+		if !seen[r.Name] {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// defaultTemplateNodes tags each node with its GNS3 node type and fills in
+// the built-in template when none was given.
+func defaultTemplateNodes(nodes []TemplateNode, kind, template string) {
+	for i := range nodes {
+		nodes[i].Kind = kind
+		if nodes[i].TemplateName == "" {
+			nodes[i].TemplateName = template
+		}
+	}
+}
+
+// TemplateNodes returns the VPCS, NAT and hub nodes in declaration order.
+func (t Topology) TemplateNodes() []TemplateNode {
+	var out []TemplateNode
+	for _, group := range [][]TemplateNode{t.VPCS, t.NAT, t.Hubs} {
+		out = append(out, group...)
+	}
+	return out
+}
+
+// inherit fills every unset field of q from def.
+func (q *QemuResources) inherit(def QemuResources) {
+	if q.RAM == 0 {
+		q.RAM = def.RAM
+	}
+	if q.CPUs == 0 {
+		q.CPUs = def.CPUs
+	}
+	if q.Adapters == 0 {
+		q.Adapters = def.Adapters
+	}
+	if q.AdapterType == "" {
+		q.AdapterType = def.AdapterType
+	}
+	if q.Platform == "" {
+		q.Platform = def.Platform
+	}
+	if q.ConsoleType == "" {
+		q.ConsoleType = def.ConsoleType
+	}
+	if q.Options == "" {
+		q.Options = def.Options
+	}
+}
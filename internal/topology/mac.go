@@ -0,0 +1,75 @@
+package topology
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+)
+
+// DefaultMACPrefix is the locally administered prefix of derived MAC
+// addresses when project.mac_prefix is not set.
+const DefaultMACPrefix = "02:4e:44"
+
+// ParseMACPrefix parses a three-octet MAC prefix and checks that it is
+// locally administered and unicast, so derived addresses never clash with
+// real hardware.
+func ParseMACPrefix(prefix string) ([3]byte, error) {
+	var out [3]byte
+	hw, err := net.ParseMAC(prefix + ":00:00:00")
+	if err != nil || len(hw) != 6 {
+		return out, fmt.Errorf("%q is not a MAC prefix like %s", prefix, DefaultMACPrefix)
+	}
+	if hw[0]&0x02 == 0 || hw[0]&0x01 != 0 {
+		return out, fmt.Errorf("%q is not a locally administered unicast prefix (first octet must be x2, x6, xA or xE)", prefix)
+	}
+	copy(out[:], hw[:3])
+	return out, nil
+}
+
+// DeriveMAC returns the MAC address of a node: the prefix followed by three
+// bytes of a hash of the project and node names. salt is bumped to step
+// around collisions.
+func DeriveMAC(prefix [3]byte, project, node string, salt int) string {
+	key := project + "/" + node
+	if salt > 0 {
+		key = fmt.Sprintf("%s#%d", key, salt)
+	}
+	sum := sha256.Sum256([]byte(key))
+	hw := net.HardwareAddr{prefix[0], prefix[1], prefix[2], sum[0], sum[1], sum[2]}
+	return hw.String()
+}
+
+// assignMACs gives every QEMU router without a mac_address one derived from
+// the project and node names. Addresses typed into the file win; derived
+// ones step around them and each other.
+func (t *Topology) assignMACs() {
+	prefix, err := ParseMACPrefix(t.Project.MACPrefix)
+	if t.Project.MACPrefix == "" {
+		prefix, err = ParseMACPrefix(DefaultMACPrefix)
+	}
+	if err != nil {
+		return // reported by validation
+	}
+
+	used := make(map[string]bool)
+	for _, r := range t.NetworkDevice.Routers {
+		if hw, err := net.ParseMAC(r.MacAddress); err == nil {
+			used[hw.String()] = true
+		}
+	}
+	for i := range t.NetworkDevice.Routers {
+		r := &t.NetworkDevice.Routers[i]
+		if r.MacAddress != "" || r.Name == "" {
+			continue
+		}
+		for salt := 0; ; salt++ {
+			mac := DeriveMAC(prefix, t.Project.Name, r.Name, salt)
+			if !used[mac] {
+				used[mac] = true
+				r.MacAddress = mac
+				r.MacDerived = true
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package topology
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMACPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   [3]byte
+		err    string
+	}{
+		{prefix: "02:4e:44", want: [3]byte{0x02, 0x4e, 0x44}},
+		{prefix: "0A:00:01", want: [3]byte{0x0a, 0x00, 0x01}},
+		{prefix: "02:4e", err: "is not a MAC prefix"},
+		{prefix: "02:4e:44:00", err: "is not a MAC prefix"},
+		{prefix: "00:1c:73", err: "not a locally administered unicast prefix"}, // vendor OUI
+		{prefix: "03:00:00", err: "not a locally administered unicast prefix"}, // multicast
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			got, err := ParseMACPrefix(tt.prefix)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseMACPrefix(%q) = %x, %v, want %x", tt.prefix, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeriveMAC(t *testing.T) {
+	prefix := [3]byte{0x02, 0x4e, 0x44}
+	mac := DeriveMAC(prefix, "lab", "R1", 0)
+	if !strings.HasPrefix(mac, "02:4e:44:") || len(mac) != len("02:4e:44:00:00:00") {
+		t.Fatalf("DeriveMAC = %q, want 02:4e:44:xx:xx:xx", mac)
+	}
+	if again := DeriveMAC(prefix, "lab", "R1", 0); again != mac {
+		t.Errorf("DeriveMAC is not stable: %q then %q", mac, again)
+	}
+	for _, other := range []string{
+		DeriveMAC(prefix, "lab", "R2", 0),
+		DeriveMAC(prefix, "lab2", "R1", 0),
+		DeriveMAC(prefix, "lab", "R1", 1),
+	} {
+		if other == mac {
+			t.Errorf("DeriveMAC gave %q for a different project, node or salt", mac)
+		}
+	}
+}
+
+func TestAssignMACs(t *testing.T) {
+	prefix := [3]byte{0x02, 0x4e, 0x44}
+	r1 := DeriveMAC(prefix, "lab", "R1", 0)
+	tests := []struct {
+		name      string
+		macPrefix string
+		routers   []NetworkDevice
+		want      []string
+		derived   []bool
+	}{
+		{
+			name:    "derived from project and node names",
+			routers: []NetworkDevice{{Name: "R1"}, {Name: "R2"}},
+			want:    []string{r1, DeriveMAC(prefix, "lab", "R2", 0)},
+			derived: []bool{true, true},
+		},
+		{
+			name:    "typed address is kept",
+			routers: []NetworkDevice{{Name: "R1", MacAddress: "00:1c:73:aa:bc:01"}, {Name: "R2"}},
+			want:    []string{"00:1c:73:aa:bc:01", DeriveMAC(prefix, "lab", "R2", 0)},
+			derived: []bool{false, true},
+		},
+		{
+			name:    "derived address steps around a typed one",
+			routers: []NetworkDevice{{Name: "R2", MacAddress: strings.ToUpper(r1)}, {Name: "R1"}},
+			want:    []string{strings.ToUpper(r1), DeriveMAC(prefix, "lab", "R1", 1)},
+			derived: []bool{false, true},
+		},
+		{
+			name:      "project prefix",
+			macPrefix: "0a:00:01",
+			routers:   []NetworkDevice{{Name: "R1"}},
+			want:      []string{DeriveMAC([3]byte{0x0a, 0x00, 0x01}, "lab", "R1", 0)},
+			derived:   []bool{true},
+		},
+		{
+			name:      "invalid prefix derives nothing",
+			macPrefix: "00:1c:73",
+			routers:   []NetworkDevice{{Name: "R1"}},
+			want:      []string{""},
+			derived:   []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var topo Topology
+			topo.Project.Name = "lab"
+			topo.Project.MACPrefix = tt.macPrefix
+			topo.NetworkDevice.Routers = tt.routers
+			topo.assignMACs()
+			for i, r := range topo.NetworkDevice.Routers {
+				if r.MacAddress != tt.want[i] || r.MacDerived != tt.derived[i] {
+					t.Errorf("%s: mac_address = %q (derived %v), want %q (derived %v)",
+						r.Name, r.MacAddress, r.MacDerived, tt.want[i], tt.derived[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,356 @@
+package topology
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the topology format version this build reads. Files
+// declare it with a top-level version key; files without one are either
+// legacy (version 0) or predate the key (treated as current).
+const CurrentVersion = 1
+
+// VersionError reports a topology file written for another format version.
+type VersionError struct {
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	if e.Version < CurrentVersion {
+		return fmt.Sprintf("topology uses format version %d; run 'netdevops migrate' to convert it to version %d", e.Version, CurrentVersion)
+	}
+	return fmt.Sprintf("topology uses format version %d, newer than the supported version %d; upgrade netdevops", e.Version, CurrentVersion)
+}
+
+// migrations[v] converts a document from version v to v+1 in place and
+// returns notes about everything it could not carry over.
+var migrations = []func(doc *yaml.Node) []string{
+	migrateV0,
+}
+
+// DetectVersion returns the format version of a decoded document: the
+// version key when present, otherwise 0 for the legacy shapes (project as
+// a plain string, top-level routers, network-device as a list) and
+// CurrentVersion for anything else.
+func DetectVersion(doc *yaml.Node) (int, error) {
+	if doc.Kind != yaml.MappingNode {
+		return CurrentVersion, nil
+	}
+	if v := mapGet(doc, "version"); v != nil {
+		var n int
+		if err := v.Decode(&n); err != nil {
+			return 0, fmt.Errorf("line %d: version must be an integer", v.Line)
+		}
+		return n, nil
+	}
+	if p := mapGet(doc, "project"); p != nil && p.Kind == yaml.ScalarNode {
+		return 0, nil
+	}
+	if mapGet(doc, "routers") != nil {
+		return 0, nil
+	}
+	if nd := mapGet(doc, "network-device"); nd != nil && nd.Kind == yaml.SequenceNode {
+		return 0, nil
+	}
+	return CurrentVersion, nil
+}
+
+// CheckVersion returns a VersionError unless data is in the current format.
+func CheckVersion(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return &ParseError{Err: err}
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+	v, err := DetectVersion(root.Content[0])
+	if err != nil {
+		return &ParseError{Err: err}
+	}
+	if v != CurrentVersion {
+		return &VersionError{Version: v}
+	}
+	return nil
+}
+
+// Migrate converts topology YAML of any older format version to the current
+// one, keeping key order and comments. It returns the new document and a
+// note for every field that had no place in the current format.
+func Migrate(data []byte) ([]byte, []string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, nil, &ParseError{Err: err}
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("topology must be a YAML mapping")
+	}
+	doc := root.Content[0]
+	from, err := DetectVersion(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if from > CurrentVersion {
+		return nil, nil, &VersionError{Version: from}
+	}
+
+	var notes []string
+	for v := from; v < CurrentVersion; v++ {
+		notes = append(notes, migrations[v](doc)...)
+	}
+	mapDelete(doc, "version")
+	doc.Content = append([]*yaml.Node{scalar("version"), scalar(fmt.Sprint(CurrentVersion))}, doc.Content...)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), notes, nil
+}
+
+// migrateV0 converts the legacy layouts: project as a string with
+// start_nodes, terraform_version, gns3_server and ztp_server at the top
+// level, routers at the top level keyed by template, network-device as a
+// plain list, and config as a single map.
+func migrateV0(doc *yaml.Node) []string {
+	var notes []string
+
+	// project: "name" plus top-level settings → project mapping
+	project := mapGet(doc, "project")
+	if project != nil && project.Kind == yaml.ScalarNode {
+		pm := &yaml.Node{Kind: yaml.MappingNode}
+		mapSet(pm, "name", project)
+		mapSet(doc, "project", pm)
+		project = pm
+	}
+	if project == nil {
+		project = &yaml.Node{Kind: yaml.MappingNode}
+		mapSet(doc, "project", project)
+	}
+	for _, key := range []string{"start_nodes", "terraform_version", "gns3_server"} {
+		if v := mapDelete(doc, key); v != nil && mapGet(project, key) == nil {
+			mapSet(project, key, v)
+		}
+	}
+	if mapGet(project, "terraform_version") == nil {
+		notes = append(notes, "project.terraform_version: not in the legacy file; set it to the netopschic/gns3 provider version")
+	}
+
+	// network-device: [...] → network-device: {routers: [...]}
+	if nd := mapGet(doc, "network-device"); nd != nil && nd.Kind == yaml.SequenceNode {
+		m := &yaml.Node{Kind: yaml.MappingNode}
+		mapSet(m, "routers", nd)
+		mapSet(doc, "network-device", m)
+	}
+
+	// routers: [{template: ...}] → templates.routers / templates.servers
+	templates := mapGet(doc, "templates")
+	if templates == nil && (mapGet(doc, "routers") != nil || mapGet(doc, "ztp_server") != nil) {
+		// Put templates where the legacy routers were, to keep the file's order.
+		templates = &yaml.Node{Kind: yaml.MappingNode}
+		at := len(doc.Content)
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			if doc.Content[i].Value == "routers" {
+				at = i
+				break
+			}
+		}
+		doc.Content = append(doc.Content[:at], append([]*yaml.Node{scalar("templates"), templates}, doc.Content[at:]...)...)
+	}
+	legacyRouters := mapDelete(doc, "routers")
+	ztp := mapDelete(doc, "ztp_server")
+	var ztpServer *yaml.Node
+	if legacyRouters != nil {
+		for i, r := range legacyRouters.Content {
+			if r.Kind != yaml.MappingNode {
+				continue
+			}
+			if mapGet(r, "template_name") == nil {
+				mapRename(r, "template", "template_name")
+			}
+			tmpl := ""
+			if t := mapGet(r, "template_name"); t != nil {
+				tmpl = t.Value
+			}
+			if strings.Contains(strings.ToLower(tmpl), "ztp") {
+				// The ZTP appliance is a server in the current format.
+				path := fmt.Sprintf("routers[%d]", i)
+				notes = append(notes, dropUnknown(r, reflect.TypeOf(TemplateServer{}), path)...)
+				if mapGet(r, "start") == nil {
+					mapSet(r, "start", scalar("true"))
+				}
+				appendSeq(templates, "servers", r)
+				ztpServer = r
+				notes = append(notes, fmt.Sprintf("%s: moved to templates.servers as a ZTP server", path))
+				continue
+			}
+			notes = append(notes, dropUnknown(r, reflect.TypeOf(Router{}), fmt.Sprintf("routers[%d]", i))...)
+			appendSeq(templates, "routers", r)
+		}
+	}
+	if ztp != nil {
+		if ztpServer == nil {
+			ztpServer = &yaml.Node{Kind: yaml.MappingNode}
+			mapSet(ztpServer, "name", scalar("ztp-server"))
+			mapSet(ztpServer, "template_name", scalar("ztp-server"))
+			mapSet(ztpServer, "start", scalar("true"))
+			appendSeq(templates, "servers", ztpServer)
+			notes = append(notes, "ztp_server: added templates.servers entry \"ztp-server\" (template ztp-server); adjust the template name if yours differs")
+		}
+		mapSet(ztpServer, "ztp_server", ztp)
+	}
+
+	if nd := mapGet(doc, "network-device"); nd != nil {
+		if routers := mapGet(nd, "routers"); routers != nil {
+			for i, r := range routers.Content {
+				path := fmt.Sprintf("network-device.routers[%d]", i)
+				notes = append(notes, migrateConfig(r, path)...)
+				notes = append(notes, dropUnknown(r, reflect.TypeOf(NetworkDevice{}), path)...)
+			}
+		}
+	}
+	if templates != nil {
+		if routers := mapGet(templates, "routers"); routers != nil {
+			for i, r := range routers.Content {
+				notes = append(notes, migrateConfig(r, fmt.Sprintf("templates.routers[%d]", i))...)
+			}
+		}
+	}
+
+	notes = append(notes, dropUnknown(doc, reflect.TypeOf(Topology{}), "")...)
+	return notes
+}
+
+// migrateConfig turns a single config map into a list and drops the keys a
+// config block does not have (e.g. ospfv3).
+func migrateConfig(router *yaml.Node, path string) []string {
+	if router.Kind != yaml.MappingNode {
+		return nil
+	}
+	cfg := mapGet(router, "config")
+	if cfg == nil {
+		return nil
+	}
+	if cfg.Kind == yaml.MappingNode {
+		cfg = &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{cfg}}
+		mapSet(router, "config", cfg)
+	}
+	var notes []string
+	for j, block := range cfg.Content {
+		notes = append(notes, dropUnknown(block, reflect.TypeOf(ConfigBlock{}), fmt.Sprintf("%s.config[%d]", path, j))...)
+	}
+	return notes
+}
+
+// dropUnknown removes the keys of m that typ has no field for and returns a
+// note for each.
+func dropUnknown(m *yaml.Node, typ reflect.Type, path string) []string {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	known := yamlKeys(typ)
+	var notes []string
+	for i := 0; i < len(m.Content)-1; {
+		key := m.Content[i].Value
+		if known[key] {
+			i += 2
+			continue
+		}
+		p := key
+		if path != "" {
+			p = path + "." + key
+		}
+		notes = append(notes, fmt.Sprintf("%s (line %d): no equivalent in the current format, removed", p, m.Content[i].Line))
+		m.Content = append(m.Content[:i], m.Content[i+2:]...)
+	}
+	return notes
+}
+
+// yamlKeys returns the YAML keys a struct type decodes, following inline
+// fields.
+func yamlKeys(typ reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if strings.Contains(opts, "inline") {
+			for k := range yamlKeys(f.Type) {
+				keys[k] = true
+			}
+			continue
+		}
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		keys[name] = true
+	}
+	return keys
+}
+
+func scalar(v string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: v}
+}
+
+func mapGet(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mapSet replaces the value of key, or appends key when it is missing.
+func mapSet(m *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = v
+			return
+		}
+	}
+	m.Content = append(m.Content, scalar(key), v)
+}
+
+// mapDelete removes key and returns its value, or nil when it is missing.
+func mapDelete(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v := m.Content[i+1]
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return v
+		}
+	}
+	return nil
+}
+
+// mapRename renames key in place, keeping its position and comments.
+func mapRename(m *yaml.Node, key, to string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i].Value = to
+			return
+		}
+	}
+}
+
+func appendSeq(m *yaml.Node, key string, item *yaml.Node) {
+	seq := mapGet(m, key)
+	if seq == nil {
+		seq = &yaml.Node{Kind: yaml.SequenceNode}
+		mapSet(m, key, seq)
+	}
+	seq.Content = append(seq.Content, item)
+}
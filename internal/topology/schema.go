@@ -0,0 +1,177 @@
+package topology
+
+import (
+	_ "embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Schema is the JSON Schema of the topology format. It is printed by
+// `netdevops schema` so editors running yaml-language-server can validate
+// and autocomplete topology files.
+//
+//go:embed schema.json
+var Schema []byte
+
+// Diagnostic is a single schema violation located in the source YAML.
+type Diagnostic struct {
+	Path    string // dotted path, e.g. network-device.routers[0].vendor
+	Line    int
+	Column  int
+	Message string
+}
+
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Path, d.Message)
+}
+
+// ValidateSchema checks raw topology YAML against Schema and returns one
+// Diagnostic per violation, sorted by position. A non-nil error means the
+// document is not valid YAML at all.
+func ValidateSchema(data []byte) ([]Diagnostic, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+	if len(root.Content) == 0 {
+		return []Diagnostic{{Line: 1, Column: 1, Message: "document is empty"}}, nil
+	}
+	doc := root.Content[0]
+
+	var value interface{}
+	if err := doc.Decode(&value); err != nil {
+		return nil, &ParseError{Err: err}
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(Schema),
+		gojsonschema.NewGoLoader(jsonCompatible(value)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("schema validation: %w", err)
+	}
+
+	var diags []Diagnostic
+	for _, re := range result.Errors() {
+		// oneOf/anyOf summaries repeat what the branch errors already say.
+		if re.Type() == "number_one_of" || re.Type() == "number_any_of" {
+			continue
+		}
+		segs := contextSegments(re.Context())
+		if prop, ok := re.Details()["property"].(string); ok && re.Type() == "additional_property_not_allowed" {
+			segs = append(segs, prop)
+		}
+		node := lookupNode(doc, segs, re.Type() == "additional_property_not_allowed")
+		diags = append(diags, Diagnostic{
+			Path:    formatPath(segs),
+			Line:    node.Line,
+			Column:  node.Column,
+			Message: strings.TrimPrefix(re.Description(), re.Field()+" "),
+		})
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Column < diags[j].Column
+	})
+	return diags, nil
+}
+
+// contextSegments splits a gojsonschema context into its path segments,
+// dropping the "(root)" head.
+func contextSegments(ctx *gojsonschema.JsonContext) []string {
+	if ctx == nil {
+		return nil
+	}
+	parts := strings.Split(ctx.String("\x00"), "\x00")
+	if len(parts) > 0 && parts[0] == gojsonschema.STRING_CONTEXT_ROOT {
+		parts = parts[1:]
+	}
+	return parts
+}
+
+// lookupNode walks the YAML tree along segs and returns the deepest node it
+// can reach. When keyNode is set the mapping key is returned instead of its
+// value, which points unknown-property errors at the offending key.
+func lookupNode(n *yaml.Node, segs []string, keyNode bool) *yaml.Node {
+	cur := n
+	for i, seg := range segs {
+		last := i == len(segs)-1
+		switch cur.Kind {
+		case yaml.MappingNode:
+			found := false
+			for j := 0; j+1 < len(cur.Content); j += 2 {
+				if cur.Content[j].Value == seg {
+					if last && keyNode {
+						return cur.Content[j]
+					}
+					cur = cur.Content[j+1]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return cur
+			}
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(seg)
+			if err != nil || idx < 0 || idx >= len(cur.Content) {
+				return cur
+			}
+			cur = cur.Content[idx]
+		default:
+			return cur
+		}
+	}
+	return cur
+}
+
+// formatPath renders path segments as network-device.routers[0].vendor.
+func formatPath(segs []string) string {
+	var b strings.Builder
+	for _, s := range segs {
+		if _, err := strconv.Atoi(s); err == nil {
+			fmt.Fprintf(&b, "[%s]", s)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
+
+// jsonCompatible converts decoded YAML into values encoding/json accepts,
+// turning any map[interface{}]interface{} into map[string]interface{}.
+func jsonCompatible(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			t[k] = jsonCompatible(val)
+		}
+		return t
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			m[fmt.Sprint(k)] = jsonCompatible(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range t {
+			t[i] = jsonCompatible(val)
+		}
+		return t
+	default:
+		return v
+	}
+}
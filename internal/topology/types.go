@@ -0,0 +1,316 @@
+// Package topology holds the typed model of a topology YAML file and the
+// loader every command uses to read it.
+package topology
+
+import (
+	"fmt"
+	"time"
+)
+
+// Topology represents the complete network topology shared between CLI and YAML modes.
+type Topology struct {
+	Version int `yaml:"version,omitempty"` // format version, see CurrentVersion
+
+	Project struct {
+		Name             string `yaml:"name"`
+		StartNodes       bool   `yaml:"start_nodes"`
+		GNS3Server       string `yaml:"gns3_server"`
+		TerraformVersion string `yaml:"terraform_version"`
+		// MACPrefix is the locally administered prefix of derived MAC
+		// addresses, e.g. 02:4e:44.
+		MACPrefix string `yaml:"mac_prefix,omitempty"`
+		// Drift overrides, per node property, what the reconciler does
+		// when GNS3 no longer matches the topology: update, recreate or
+		// warn.
+		Drift map[string]string `yaml:"drift,omitempty"`
+		// Prune limits what the reconciler may delete.
+		Prune Prune `yaml:"prune,omitempty"`
+		// Reconcile tunes the reconcile daemon's cadence.
+		Reconcile ReconcileSettings `yaml:"reconcile,omitempty"`
+		// Layout places nodes without x/y: auto, layered, force or none.
+		Layout string `yaml:"layout,omitempty"`
+		// Defaults are the compute settings every QEMU router inherits
+		// unless it overrides them.
+		Defaults QemuResources `yaml:"defaults"`
+	} `yaml:"project"`
+
+	NetworkDevice struct {
+		Routers []NetworkDevice `yaml:"routers,omitempty"`
+	} `yaml:"network-device"`
+
+	Switches  []Switch      `yaml:"switches,omitempty"`
+	Clouds    []Cloud       `yaml:"clouds,omitempty"`
+	Templates TemplateGroup `yaml:"templates"`
+	Links     []Link        `yaml:"links,omitempty"`
+
+	Docker   []DockerNode   `yaml:"docker,omitempty"`
+	VPCS     []TemplateNode `yaml:"vpcs,omitempty"`
+	NAT      []TemplateNode `yaml:"nat,omitempty"`
+	Hubs     []TemplateNode `yaml:"hubs,omitempty"`
+	IOU      []IOUNode      `yaml:"iou,omitempty"`
+	Dynamips []DynamipsNode `yaml:"dynamips,omitempty"`
+
+	IPAM   *IPAM   `yaml:"ipam,omitempty"`
+	Fabric *Fabric `yaml:"fabric,omitempty"` // expanded into routers and links at load time
+
+	tiers map[string]int // fabric row of expanded nodes, used by the layout
+
+	ZTPServer        string            `yaml:"-"` // Extracted from ztp-server in templates
+	LinkIDs          map[string]string `yaml:"-"`
+	NetworkDeviceIDs map[string]string `yaml:"-"`
+}
+
+// Prune limits what the reconciler deletes from a GNS3 project that other
+// people may share.
+type Prune struct {
+	Policy       string   `yaml:"policy,omitempty"`        // never, managed-only (default) or all
+	Protect      []string `yaml:"protect,omitempty"`       // node name patterns never deleted
+	MaxDeletions int      `yaml:"max_deletions,omitempty"` // abort a pass deleting more; 0 is no limit
+}
+
+// ReconcileSettings tune when the reconcile daemon runs a pass. Zero values
+// take the daemon's defaults; gns3-deploy flags override them.
+type ReconcileSettings struct {
+	Interval    time.Duration `yaml:"interval,omitempty"`     // between periodic passes
+	Jitter      time.Duration `yaml:"jitter,omitempty"`       // random extra delay per pass
+	Debounce    time.Duration `yaml:"debounce,omitempty"`     // quiet time after a file change
+	MaxBackoff  time.Duration `yaml:"max_backoff,omitempty"`  // cap of the delay after failed passes
+	MaxFailures int           `yaml:"max_failures,omitempty"` // consecutive failures before the daemon stops; 0 never stops
+}
+
+// NetworkDevice defines a QEMU router built from a disk image.
+type NetworkDevice struct {
+	Name       string     `yaml:"name"`
+	Hostname   string     `yaml:"hostname,omitempty"`
+	Vendor     string     `yaml:"vendor"`
+	MacAddress string     `yaml:"mac_address,omitempty"`
+	Image      string     `yaml:"image"`
+	Config     ConfigList `yaml:"config,omitempty"`
+	Port       int        `yaml:"-"`
+	MacDerived bool       `yaml:"-"` // MacAddress was derived, not typed into the file
+
+	QemuResources `yaml:",inline"`
+	Position      `yaml:",inline"`
+}
+
+// QemuResources are the compute settings of a QEMU router. Zero values are
+// inherited from project.defaults and then from the built-in defaults when
+// the topology is loaded.
+type QemuResources struct {
+	RAM         int    `yaml:"ram,omitempty"` // MB
+	CPUs        int    `yaml:"cpus,omitempty"`
+	Adapters    int    `yaml:"adapters,omitempty"`
+	AdapterType string `yaml:"adapter_type,omitempty"`
+	Platform    string `yaml:"platform,omitempty"`
+	ConsoleType string `yaml:"console_type,omitempty"`
+	Options     string `yaml:"options,omitempty"` // extra QEMU command-line options
+}
+
+type TemplateGroup struct {
+	Servers []TemplateServer `yaml:"servers,omitempty"`
+	Routers []Router         `yaml:"routers,omitempty"`
+}
+
+type TemplateServer struct {
+	Name         string `yaml:"name"`
+	TemplateName string `yaml:"template_name"`
+	Start        bool   `yaml:"start"`
+	ZTPServer    string `yaml:"ztp_server,omitempty"`    // Only applicable to ztp-server
+	ObserveTower string `yaml:"observe-tower,omitempty"` // Only applicable to observe-tower
+
+	Position `yaml:",inline"`
+}
+
+// Router defines a router device.
+type Router struct {
+	Name         string     `yaml:"name"`
+	Hostname     string     `yaml:"hostname"`
+	Vendor       string     `yaml:"vendor"` // Added to support YAML input (e.g., "arista")
+	Template     string     `yaml:"template,omitempty"`
+	Config       ConfigList `yaml:"config,omitempty"`
+	Start        bool       `yaml:"start"`
+	TemplateName string     `yaml:"template_name"`
+
+	Position `yaml:",inline"`
+}
+
+// Switch defines a switch device.
+type Switch struct {
+	Name string `yaml:"name"`
+
+	Position `yaml:",inline"`
+}
+
+// Cloud defines a cloud device.
+type Cloud struct {
+	Name string `yaml:"name"`
+
+	Position `yaml:",inline"`
+}
+
+// DockerNode defines a Docker container end host.
+type DockerNode struct {
+	Name         string            `yaml:"name"`
+	Image        string            `yaml:"image"`
+	Adapters     int               `yaml:"adapters,omitempty"`
+	StartCommand string            `yaml:"start_command,omitempty"`
+	Environment  map[string]string `yaml:"environment,omitempty"`
+	ConsoleType  string            `yaml:"console_type,omitempty"`
+
+	Position `yaml:",inline"`
+}
+
+// TemplateNode is a node instantiated from a GNS3 template by name. VPCS,
+// NAT and hub nodes default to the templates GNS3 ships with.
+type TemplateNode struct {
+	Name         string `yaml:"name"`
+	TemplateName string `yaml:"template_name,omitempty"`
+	Kind         string `yaml:"-"` // vpcs, nat or ethernet_hub
+
+	Position `yaml:",inline"`
+}
+
+// IOUNode defines a Cisco IOU node. With an image it is built from its own
+// properties; otherwise it is instantiated from template_name and the
+// properties it sets override the template's.
+type IOUNode struct {
+	Name             string `yaml:"name"`
+	TemplateName     string `yaml:"template_name,omitempty"`
+	Image            string `yaml:"image,omitempty"` // IOU binary on the compute
+	RAM              int    `yaml:"ram,omitempty"`   // MB
+	NVRAM            int    `yaml:"nvram,omitempty"` // KB
+	EthernetAdapters int    `yaml:"ethernet_adapters,omitempty"`
+	SerialAdapters   int    `yaml:"serial_adapters,omitempty"`
+
+	Position `yaml:",inline"`
+}
+
+// Adapters returns the number of adapters of an IOU node, Ethernet first
+// then serial, or 0 when its template decides.
+func (n IOUNode) Adapters() int {
+	if n.Image == "" {
+		return 0
+	}
+	return n.EthernetAdapters + n.SerialAdapters
+}
+
+// DynamipsNode defines a Dynamips router. Like IOUNode it is built from its
+// image when one is set and from template_name otherwise.
+type DynamipsNode struct {
+	Name         string   `yaml:"name"`
+	TemplateName string   `yaml:"template_name,omitempty"`
+	Platform     string   `yaml:"platform,omitempty"` // c7200, c3745, c3725, c3600, c2691, c2600 or c1700
+	Image        string   `yaml:"image,omitempty"`
+	RAM          int      `yaml:"ram,omitempty"`   // MB
+	NVRAM        int      `yaml:"nvram,omitempty"` // KB
+	Slots        []string `yaml:"slots,omitempty"` // network module per slot from slot0, e.g. C7200-IO-FE; "" leaves a slot empty
+	WICs         []string `yaml:"wics,omitempty"`  // WAN interface card per WIC slot from wic0
+	IdlePC       string   `yaml:"idlepc,omitempty"`
+
+	Position `yaml:",inline"`
+}
+
+// Endpoint defines a device interface, including adapter and port numbers.
+// When Interface is set (e.g. Ethernet2) the loader derives Adapter and Port
+// from it using the node's vendor mapping.
+type Endpoint struct {
+	Name      string `yaml:"name"`
+	Interface string `yaml:"interface,omitempty"`
+	Adapter   int    `yaml:"adapter"`
+	Port      int    `yaml:"port"`
+
+	adapterSet, portSet bool // typed into the file next to Interface
+}
+
+func (e *Endpoint) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Endpoint
+	if err := unmarshal((*plain)(e)); err != nil {
+		return err
+	}
+	var given struct {
+		Adapter *int `yaml:"adapter"`
+		Port    *int `yaml:"port"`
+	}
+	if err := unmarshal(&given); err != nil {
+		return err
+	}
+	e.adapterSet, e.portSet = given.Adapter != nil, given.Port != nil
+	return nil
+}
+
+// Link defines a connection between two endpoints.
+type Link struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+// ResourceName is the Terraform name of the link's gns3_link resource.
+func (l Link) ResourceName() string {
+	return LinkResourceName(l.Endpoints[0], l.Endpoints[1])
+}
+
+// LinkResourceName names the gns3_link between a and b after both nodes and
+// ports, e.g. R1_1_0_to_R2_1_0, so parallel links between the same two nodes
+// get distinct names. A port takes a single cable, so the name is unique.
+func LinkResourceName(a, b Endpoint) string {
+	return fmt.Sprintf("%s_%d_%d_to_%s_%d_%d", a.Name, a.Adapter, a.Port, b.Name, b.Adapter, b.Port)
+}
+
+// ConfigList is a list of configuration blocks, supporting a single block or a list.
+type ConfigList []*ConfigBlock
+
+func (cl *ConfigList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []*ConfigBlock
+	if err := unmarshal(&list); err == nil {
+		*cl = list
+		return nil
+	}
+	var single ConfigBlock
+	if err := unmarshal(&single); err != nil {
+		return err
+	}
+	*cl = []*ConfigBlock{&single}
+	return nil
+}
+
+// ConfigBlock is one entry of a router's config list: an interface address,
+// static routes, or a routing protocol section.
+type ConfigBlock struct {
+	Interface    string `yaml:"interface,omitempty"`
+	IPAddress    string `yaml:"ip_address,omitempty"`
+	StaticRoutes []struct {
+		DestNetwork string `yaml:"dest_network"`
+		SubnetMask  string `yaml:"subnet_mask"`
+		NextHop     string `yaml:"next_hop"`
+		Interface   string `yaml:"interface,omitempty"`
+	} `yaml:"static_routes,omitempty"`
+	OSPF *struct {
+		RouterID   string   `yaml:"router_id"`
+		Area       string   `yaml:"area"`
+		Networks   []string `yaml:"networks,omitempty"`
+		Interfaces []struct {
+			Name    string `yaml:"name"`
+			Cost    int    `yaml:"cost"`
+			Passive bool   `yaml:"passive"`
+		} `yaml:"interfaces,omitempty"`
+		Stub         interface{}      `yaml:"stub,omitempty"`
+		NSSA         interface{}      `yaml:"nssa,omitempty"`
+		Redistribute []Redistribution `yaml:"redistribute,omitempty"`
+	} `yaml:"ospf,omitempty"`
+	BGP *struct {
+		LocalAS      int              `yaml:"local_as"`
+		RouterID     string           `yaml:"router_id"`
+		RemoteAS     int              `yaml:"remote_as"`
+		Neighbor     string           `yaml:"neighbor"`
+		Networks     []string         `yaml:"networks,omitempty"`
+		Redistribute []Redistribution `yaml:"redistribute,omitempty"`
+	} `yaml:"bgp,omitempty"`
+}
+
+// Redistribution represents a redistribution rule.
+type Redistribution struct {
+	Protocol  string `yaml:"protocol"`
+	Metric    int    `yaml:"metric,omitempty"`
+	RouteMap  string `yaml:"route_map,omitempty"`
+	IsisLevel string `yaml:"isis_level,omitempty"` // Optional; if provided, passed to module
+	OspfRoute string `yaml:"ospf_route,omitempty"`
+}
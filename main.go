@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"netdevops-cli-tool/cmd"
 	"os"
@@ -10,20 +11,27 @@ func main() {
 	// Magic background reconcile entrypoint, NOT a user CLI command
 	if len(os.Args) > 1 && os.Args[1] == "__reconcile_daemon" {
 		var configFile, projectID string
-		for i := 2; i < len(os.Args)-1; i++ {
-			if os.Args[i] == "--config" {
-				configFile = os.Args[i+1]
-			}
-			if os.Args[i] == "--project-id" {
-				projectID = os.Args[i+1]
-			}
-		}
+		var opts cmd.ReconcileOptions
+		fs := flag.NewFlagSet("__reconcile_daemon", flag.ExitOnError)
+		fs.StringVar(&configFile, "config", "", "YAML topology file")
+		fs.StringVar(&projectID, "project-id", "", "GNS3 project ID")
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "only log the plan of each pass")
+		fs.DurationVar(&opts.Interval, "interval", 0, "time between periodic passes")
+		fs.DurationVar(&opts.Jitter, "jitter", 0, "random extra delay per pass")
+		fs.DurationVar(&opts.Debounce, "debounce", 0, "quiet time after a file change")
+		fs.DurationVar(&opts.MaxBackoff, "max-backoff", 0, "cap of the delay after failed passes")
+		fs.IntVar(&opts.MaxFailures, "max-failures", 0, "consecutive failed passes before stopping")
+		fs.BoolVar(&opts.NoHeal, "no-heal", false, "do not reconfigure recreated routers")
+		fs.StringVar(&opts.MetricsAddr, "metrics-addr", "", "serve Prometheus metrics on this address")
+		fs.StringVar(&opts.WebhookAddr, "webhook-addr", "", "accept Git webhooks on this address")
+		fs.BoolVar(&opts.WebhookInsecure, "webhook-insecure", false, "accept unauthenticated webhooks without a secret")
+		fs.Parse(os.Args[2:])
 		if configFile == "" || projectID == "" {
 			fmt.Fprintln(os.Stderr, "Missing --config or --project-id for reconciliation daemon")
 			os.Exit(1)
 		}
 		// This is your function from cmd/reconcile.go!
-		cmd.StartReconcileDaemon(configFile, projectID)
+		cmd.StartReconcileDaemon(configFile, projectID, opts)
 		os.Exit(0)
 	}
 